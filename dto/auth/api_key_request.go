@@ -0,0 +1,8 @@
+package auth
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100" example:"Deploy script"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=read write" example:"read,write"`
+	// ExpiresInDays defaults to 90 days
+	ExpiresInDays int `json:"expires_in_days" binding:"omitempty,min=1,max=365" example:"90"`
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	authModel "go-blog/models/auth"
+	"time"
+)
+
+type APIKeyResponse struct {
+	ID          uint       `json:"id" example:"4"`
+	Name        string     `json:"name" example:"Deploy script"`
+	Prefix      string     `json:"prefix" example:"gbk_Xb3kQ9aZ"`
+	Scopes      []string   `json:"scopes" example:"read,write"`
+	Active      bool       `json:"active" example:"true"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty" example:"203.0.113.7"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedByID *uint      `json:"created_by_id,omitempty" example:"1"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse is the only response containing the key itself
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"gbk_Xb3kQ9aZ0c1vLwq8RkTn2yHs5uDfGjPmEoAi4BxCz7N"`
+}
+
+type APIKeyRevokeResponse struct {
+	Message string `json:"message" example:"API key revoked successfully"`
+}
+
+func NewAPIKeyResponse(k authModel.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:          k.ID,
+		Name:        k.Name,
+		Prefix:      k.Prefix,
+		Scopes:      k.ScopeList(),
+		Active:      k.IsActive(),
+		ExpiresAt:   k.ExpiresAt,
+		LastUsedAt:  k.LastUsedAt,
+		LastUsedIP:  k.LastUsedIP,
+		RevokedAt:   k.RevokedAt,
+		CreatedByID: k.CreatedByID,
+		CreatedAt:   k.CreatedAt,
+	}
+}
+
+func NewCreatedAPIKeyResponse(k authModel.APIKey, key string) CreatedAPIKeyResponse {
+	return CreatedAPIKeyResponse{APIKeyResponse: NewAPIKeyResponse(k), Key: key}
+}
+
+func NewAPIKeyResponses(keys []authModel.APIKey) []APIKeyResponse {
+	response := make([]APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		response = append(response, NewAPIKeyResponse(k))
+	}
+	return response
+}
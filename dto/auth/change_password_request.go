@@ -0,0 +1,11 @@
+package auth
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"secret123"`
+	NewPassword     string `json:"new_password" binding:"required,strong_password" example:"strong@!Password123"`
+	RefreshToken    string `json:"refresh_token,omitempty" example:"dGhpc2lzYXJlZnJlc2h0b2tlbg=="`
+}
+
+type ChangePasswordResponse struct {
+	Message string `json:"message" example:"Password changed successfully"`
+}
@@ -0,0 +1,13 @@
+package auth
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"MTI6MTcwMDAwMDAwMDp1c2VyQGV4YW1wbGUuY29t.c2lnbmF0dXJl"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type VerificationResponse struct {
+	Message string `json:"message" example:"A verification link has been sent to your email address"`
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	authModel "go-blog/models/auth"
+	"time"
+)
+
+type IdentityResponse struct {
+	ID        uint      `json:"id" example:"3"`
+	Provider  string    `json:"provider" example:"google"`
+	Email     string    `json:"email" example:"user@example.com"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewIdentityResponse(i authModel.Identity) IdentityResponse {
+	return IdentityResponse{
+		ID:        i.ID,
+		Provider:  i.Provider,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt,
+	}
+}
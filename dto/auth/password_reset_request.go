@@ -0,0 +1,14 @@
+package auth
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required" example:"q3Jx0d..."`
+	NewPassword string `json:"new_password" binding:"required,strong_password" example:"strong@!Password123"`
+}
+
+type PasswordResetResponse struct {
+	Message string `json:"message" example:"If an account exists for this email, a reset link has been sent"`
+}
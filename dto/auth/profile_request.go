@@ -0,0 +1,9 @@
+package auth
+
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name,omitempty" binding:"omitempty,min=1,max=100" example:"Alice"`
+	LastName  *string `json:"last_name,omitempty" binding:"omitempty,min=1,max=100" example:"Dupont"`
+	Bio       *string `json:"bio,omitempty" binding:"omitempty,max=1000" example:"Go developer and coffee lover"`
+	AvatarURL *string `json:"avatar_url,omitempty" binding:"omitempty,max=500,http_url|len=0" example:"https://example.com/avatar.png"`
+	Website   *string `json:"website,omitempty" binding:"omitempty,max=500,http_url|len=0" example:"https://example.com"`
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	authModel "go-blog/models/auth"
+	"time"
+)
+
+type SessionResponse struct {
+	ID         uint      `json:"id" example:"12"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	IPAddress  string    `json:"ip_address" example:"203.0.113.7"`
+	Current    bool      `json:"current" example:"true"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+type SessionRevokeResponse struct {
+	Message string `json:"message" example:"Session revoked successfully"`
+	Revoked int64  `json:"revoked" example:"1"`
+}
+
+func NewSessionResponse(s authModel.Session, currentSessionID uint) SessionResponse {
+	return SessionResponse{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		Current:    s.ID == currentSessionID,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+	}
+}
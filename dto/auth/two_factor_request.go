@@ -0,0 +1,17 @@
+package auth
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required" example:"secret123"`
+	// Code is a TOTP code or an unused recovery code
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"dGhpc2lzYWNoYWxsZW5nZQ=="`
+	// Code is a TOTP code or an unused recovery code
+	Code string `json:"code" binding:"required" example:"123456"`
+}
@@ -0,0 +1,21 @@
+package auth
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauth_uri" example:"otpauth://totp/Go%20Blog:user@example.com?algorithm=SHA1&digits=6&issuer=Go+Blog&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+type RecoveryCodesResponse struct {
+	Message       string   `json:"message" example:"Two-factor authentication enabled"`
+	RecoveryCodes []string `json:"recovery_codes" example:"3f9a1-7c2e0,b41d8-09e6f"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required" example:"true"`
+	ChallengeToken    string `json:"challenge_token" example:"dGhpc2lzYWNoYWxsZW5nZQ=="`
+	ExpiresIn         int    `json:"expires_in" example:"300"`
+}
+
+type TwoFactorDisableResponse struct {
+	Message string `json:"message" example:"Two-factor authentication disabled"`
+}
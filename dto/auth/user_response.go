@@ -6,27 +6,35 @@ import (
 )
 
 type UserResponse struct {
-	ID          uint       `json:"id"`
-	Email       string     `json:"email"`
-	Role        string     `json:"role"`
-	Status      string     `json:"status"`
-	FirstName   string     `json:"first_name"`
-	LastName    string     `json:"last_name"`
-	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID               uint       `json:"id"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	Status           string     `json:"status"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Bio              string     `json:"bio"`
+	AvatarURL        string     `json:"avatar_url"`
+	Website          string     `json:"website"`
+	LastLoginAt      *time.Time `json:"last_login_at,omitempty"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
 func NewUserResponse(u user.User) UserResponse {
 	return UserResponse{
-		ID:          u.ID,
-		Email:       u.Email,
-		Role:        u.Role,
-		Status:      u.Status,
-		FirstName:   u.FirstName,
-		LastName:    u.LastName,
-		LastLoginAt: u.LastLoginAt,
-		CreatedAt:   u.CreatedAt,
-		UpdatedAt:   u.UpdatedAt,
+		ID:               u.ID,
+		Email:            u.Email,
+		Role:             u.Role,
+		Status:           u.Status,
+		FirstName:        u.FirstName,
+		LastName:         u.LastName,
+		Bio:              u.Bio,
+		AvatarURL:        u.AvatarURL,
+		Website:          u.Website,
+		LastLoginAt:      u.LastLoginAt,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
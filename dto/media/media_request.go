@@ -0,0 +1,9 @@
+package media
+
+type MediaUpdateRequest struct {
+	AltText string `json:"alt_text" binding:"max=500" example:"A gopher reading a book"`
+}
+
+type PostMediaRequest struct {
+	MediaIDs []uint `json:"media_ids" binding:"required,min=1" example:"1,2"`
+}
@@ -0,0 +1,65 @@
+package media
+
+import (
+	mediaModel "go-blog/models/media"
+	"time"
+)
+
+type MediaVariantResponse struct {
+	URL      string `json:"url" example:"/media/2025/01/4f9a0c2e7b1d3a5c-thumbnail.jpg"`
+	MimeType string `json:"mime_type" example:"image/jpeg"`
+	Size     int64  `json:"size" example:"18230"`
+	Width    int    `json:"width" example:"320"`
+	Height   int    `json:"height" example:"320"`
+}
+
+type MediaResponse struct {
+	ID         uint   `json:"id" example:"1"`
+	UploaderID *uint  `json:"uploader_id" example:"2"`
+	FileName   string `json:"file_name" example:"gopher.jpg"`
+	URL        string `json:"url" example:"/media/2025/01/4f9a0c2e7b1d3a5c.jpg"`
+	MimeType   string `json:"mime_type" example:"image/jpeg"`
+	Size       int64  `json:"size" example:"482113"`
+	Width      int    `json:"width,omitempty" example:"3000"`
+	Height     int    `json:"height,omitempty" example:"2000"`
+	AltText    string `json:"alt_text" example:"A gopher reading a book"`
+	// Variants are keyed by name: thumbnail, medium and large. Images smaller than the medium or large size
+	// do not have these variants.
+	Variants  map[string]MediaVariantResponse `json:"variants"`
+	CreatedAt time.Time                       `json:"created_at"`
+}
+
+func ToMediaResponse(media mediaModel.Media) MediaResponse {
+	variants := make(map[string]MediaVariantResponse, len(media.Variants))
+	for _, variant := range media.Variants {
+		variants[variant.Name] = MediaVariantResponse{
+			URL:      variant.URL,
+			MimeType: variant.MimeType,
+			Size:     variant.Size,
+			Width:    variant.Width,
+			Height:   variant.Height,
+		}
+	}
+
+	return MediaResponse{
+		ID:         media.ID,
+		UploaderID: media.UploaderID,
+		FileName:   media.FileName,
+		URL:        media.URL,
+		MimeType:   media.MimeType,
+		Size:       media.Size,
+		Width:      media.Width,
+		Height:     media.Height,
+		AltText:    media.AltText,
+		Variants:   variants,
+		CreatedAt:  media.CreatedAt,
+	}
+}
+
+func ToMediaResponses(media []mediaModel.Media) []MediaResponse {
+	responses := make([]MediaResponse, 0, len(media))
+	for _, m := range media {
+		responses = append(responses, ToMediaResponse(m))
+	}
+	return responses
+}
@@ -4,4 +4,6 @@ type CategoryRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description" binding:"required"`
 	ParentID    *uint  `json:"parent_id"`
+	// Version is the version the update is based on, required on update unless an If-Match header is sent
+	Version *uint `json:"version,omitempty" example:"2"`
 }
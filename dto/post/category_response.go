@@ -9,8 +9,10 @@ import (
 type CategoryResponseCreateDoc struct {
 	ID          uint      `json:"id"`
 	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
 	Description string    `json:"description"`
 	ParentID    *uint     `json:"parentId,omitempty"`
+	Version     uint      `json:"version"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
@@ -19,9 +21,11 @@ type CategoryResponseCreateDoc struct {
 type CategoryResponseDoc struct {
 	ID          uint                       `json:"id"`
 	Name        string                     `json:"name"`
+	Slug        string                     `json:"slug"`
 	Description string                     `json:"description"`
 	ParentID    *uint                      `json:"parentId,omitempty"`
 	Children    []CategoryResponseChildDoc `json:"children,omitempty"`
+	Version     uint                       `json:"version"`
 	CreatedAt   time.Time                  `json:"createdAt"`
 	UpdatedAt   time.Time                  `json:"updatedAt"`
 }
@@ -30,8 +34,10 @@ type CategoryResponseDoc struct {
 type CategoryResponseChildDoc struct {
 	ID          uint      `json:"id"`
 	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
 	Description string    `json:"description"`
 	ParentID    *uint     `json:"parentId,omitempty"`
+	Version     uint      `json:"version"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
@@ -47,9 +53,11 @@ type PaginatedCategoryResponse struct {
 type CategoryResponse struct {
 	ID          uint               `json:"id"`
 	Name        string             `json:"name"`
+	Slug        string             `json:"slug"`
 	Description string             `json:"description"`
 	ParentID    *uint              `json:"parentId,omitempty"`
 	Children    []CategoryResponse `json:"children,omitempty"`
+	Version     uint               `json:"version"`
 	CreatedAt   time.Time          `json:"createdAt"`
 	UpdatedAt   time.Time          `json:"updatedAt"`
 }
@@ -63,9 +71,11 @@ func ToCategoryResponse(cat postModel.Category) CategoryResponse {
 	return CategoryResponse{
 		ID:          cat.ID,
 		Name:        cat.Name,
+		Slug:        cat.Slug,
 		Description: cat.Description,
 		ParentID:    cat.ParentID,
 		Children:    children,
+		Version:     cat.Version,
 		CreatedAt:   cat.CreatedAt,
 		UpdatedAt:   cat.UpdatedAt,
 	}
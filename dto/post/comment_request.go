@@ -1,14 +1,22 @@
 package post
 
 type CommentRequest struct {
-	PostID   uint    `json:"post_id" binding:"required"`
-	UserID   uint    `json:"user_id" binding:"required"`
-	Content  string  `json:"content" binding:"required"`
-	ParentID *uint   `json:"parent_id,omitempty"`
-	Status   *string `json:"status,omitempty"`
+	PostID  uint   `json:"post_id" binding:"required"`
+	Content string `json:"content" binding:"required"`
+	// ContentFormat is the format of the content, html when omitted
+	ContentFormat string `json:"content_format,omitempty" binding:"omitempty,oneof=markdown html" example:"markdown"`
+	ParentID      *uint  `json:"parent_id,omitempty"`
 }
 
 type CommentUpdateRequest struct {
 	Content string `json:"content" binding:"required"`
-	Status  string `json:"status" binding:"required"`
-}
\ No newline at end of file
+	// ContentFormat is the format of the content, the current format when omitted
+	ContentFormat string `json:"content_format,omitempty" binding:"omitempty,oneof=markdown html" example:"markdown"`
+	// Version is the version the update is based on, required unless an If-Match header is sent
+	Version *uint `json:"version,omitempty" example:"2"`
+}
+
+type CommentModerationRequest struct {
+	CommentIDs []uint `json:"comment_ids" binding:"required,min=1" example:"1,2,3"`
+	Reason     string `json:"reason,omitempty" binding:"max=500" example:"Off-topic"`
+}
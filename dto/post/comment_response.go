@@ -2,29 +2,34 @@ package post
 
 import (
 	"go-blog/models/post"
+	"time"
 )
 
 // swagger:model CommentResponseDoc
 type CommentResponseDoc struct {
-	ID        uint                      `json:"id"`
-	PostID    uint                      `json:"post_id"`
-	UserID    uint                      `json:"user_id"`
-	Author    string                    `json:"author"`
-	Content   string                    `json:"content"`
-	Status    string                    `json:"status"`
-	CreatedAt string                    `json:"created_at"`
-	Children  []CommentResponseChildDoc `json:"children,omitempty"`
+	ID            uint                      `json:"id"`
+	PostID        uint                      `json:"post_id"`
+	UserID        uint                      `json:"user_id"`
+	Author        string                    `json:"author"`
+	Content       string                    `json:"content"`
+	ContentFormat string                    `json:"content_format"`
+	ContentHTML   string                    `json:"content_html"`
+	Status        string                    `json:"status"`
+	CreatedAt     string                    `json:"created_at"`
+	Children      []CommentResponseChildDoc `json:"children,omitempty"`
 }
 
 // swagger:model CommentResponseChildDoc
 type CommentResponseChildDoc struct {
-	ID        uint   `json:"id"`
-	PostID    uint   `json:"post_id"`
-	UserID    uint   `json:"user_id"`
-	Author    string `json:"author"`
-	Content   string `json:"content"`
-	Status    string `json:"status"`
-	CreatedAt string `json:"created_at"`
+	ID            uint   `json:"id"`
+	PostID        uint   `json:"post_id"`
+	UserID        uint   `json:"user_id"`
+	Author        string `json:"author"`
+	Content       string `json:"content"`
+	ContentFormat string `json:"content_format"`
+	ContentHTML   string `json:"content_html"`
+	Status        string `json:"status"`
+	CreatedAt     string `json:"created_at"`
 }
 
 // swagger:model PaginatedCommentResponse
@@ -36,14 +41,28 @@ type PaginatedCommentResponse struct {
 }
 
 type CommentResponse struct {
-	ID        uint               `json:"id"`
-	PostID    uint               `json:"post_id"`
-	UserID    uint               `json:"user_id"`
-	Author    string             `json:"author"`
-	Content   string             `json:"content"`
-	Status    string             `json:"status"`
-	CreatedAt string             `json:"created_at"`
-	Children  []*CommentResponse `json:"children,omitempty"`
+	ID            uint   `json:"id"`
+	PostID        uint   `json:"post_id"`
+	UserID        uint   `json:"user_id"`
+	Author        string `json:"author"`
+	Content       string `json:"content"`
+	ContentFormat string `json:"content_format" example:"markdown"`
+	// ContentHTML is the content rendered into sanitized HTML, safe to display as is
+	ContentHTML      string             `json:"content_html" example:"<p>Nice <strong>post</strong></p>"`
+	Status           string             `json:"status"`
+	ModeratedByID    *uint              `json:"moderated_by_id,omitempty"`
+	ModeratedAt      *time.Time         `json:"moderated_at,omitempty"`
+	ModerationReason string             `json:"moderation_reason,omitempty"`
+	Version          uint               `json:"version"`
+	CreatedAt        string             `json:"created_at"`
+	Children         []*CommentResponse `json:"children,omitempty"`
+}
+
+// swagger:model CommentModerationResponse
+type CommentModerationResponse struct {
+	Status     string `json:"status" example:"APPROVED"`
+	Updated    int64  `json:"updated" example:"3"`
+	CommentIDs []uint `json:"comment_ids"`
 }
 
 func ToCommentResponse(comment post.Comment) *CommentResponse {
@@ -55,13 +74,19 @@ func ToCommentResponse(comment post.Comment) *CommentResponse {
 	}
 
 	return &CommentResponse{
-		ID:        comment.ID,
-		PostID:    comment.PostID,
-		UserID:    comment.UserID,
-		Author:    authorName,
-		Content:   comment.Content,
-		Status:    comment.Status,
-		CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"), // format plus lisible
-		Children:  children,
+		ID:               comment.ID,
+		PostID:           comment.PostID,
+		UserID:           comment.UserID,
+		Author:           authorName,
+		Content:          comment.Content,
+		ContentFormat:    comment.ContentFormat,
+		ContentHTML:      comment.ContentHTML,
+		Status:           comment.Status,
+		ModeratedByID:    comment.ModeratedByID,
+		ModeratedAt:      comment.ModeratedAt,
+		ModerationReason: comment.ModerationReason,
+		Version:          comment.Version,
+		CreatedAt:        comment.CreatedAt.Format("2006-01-02 15:04:05"), // format plus lisible
+		Children:         children,
 	}
 }
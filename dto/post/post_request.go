@@ -1,8 +1,19 @@
 package post
 
+import "time"
+
 type PostRequest struct {
-	Title  string `json:"title" binding:"required"`
+	Title   string `json:"title" binding:"required"`
 	Excerpt string `json:"excerpt"`
 	Content string `json:"content" binding:"required"`
-	CategoryIDs []uint `json:"category_ids"`
+	// ContentFormat is the format of the content, html for new posts and the current format on updates when omitted
+	ContentFormat string     `json:"content_format,omitempty" binding:"omitempty,oneof=markdown html" example:"markdown"`
+	CategoryIDs   []uint     `json:"category_ids"`
+	Tags          []string   `json:"tags" binding:"dive,max=100" example:"golang,web"`
+	Status        string     `json:"status,omitempty" binding:"omitempty,oneof=DRAFT IN_REVIEW SCHEDULED PUBLISHED ARCHIVED" example:"DRAFT"`
+	PublishedAt   *time.Time `json:"published_at,omitempty" example:"2025-01-01T08:00:00Z"`
+	// CoverImageID is an image of the media library, omitting it on updates removes the cover image
+	CoverImageID *uint `json:"cover_image_id,omitempty" example:"12"`
+	// Version is the version the update is based on, required unless an If-Match header is sent
+	Version *uint `json:"version,omitempty" example:"3"`
 }
@@ -1,18 +1,32 @@
 package post
 
 import (
+	mediaDTO "go-blog/dto/media"
 	"go-blog/models/post"
 	"time"
 )
 
 type PostResponse struct {
-	ID         uint      `json:"id"`
-	Title      string    `json:"title"`
-	Excerpt    string    `json:"excerpt"`
-	Content    string    `json:"content"`
-	Categories []uint    `json:"category_ids"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID            uint   `json:"id"`
+	Title         string `json:"title"`
+	Slug          string `json:"slug"`
+	Excerpt       string `json:"excerpt"`
+	Content       string `json:"content"`
+	ContentFormat string `json:"content_format" example:"markdown"`
+	// ContentHTML is the content rendered into sanitized HTML, safe to display as is
+	ContentHTML string     `json:"content_html" example:"<p>Hello <em>world</em></p>"`
+	AuthorID    *uint      `json:"author_id"`
+	Author      string     `json:"author,omitempty"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Categories  []uint     `json:"category_ids"`
+	Tags        []string   `json:"tags"`
+	// CoverImage is the cover image of the post with its resized variants
+	CoverImageID *uint                   `json:"cover_image_id"`
+	CoverImage   *mediaDTO.MediaResponse `json:"cover_image,omitempty"`
+	Version      uint                    `json:"version" example:"3"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
 }
 
 func ToPostResponse(post post.Post) PostResponse {
@@ -22,13 +36,40 @@ func ToPostResponse(post post.Post) PostResponse {
 		categoryIDs[i] = cat.ID
 	}
 
+	tags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		tags[i] = tag.Name
+	}
+
+	var coverImage *mediaDTO.MediaResponse
+	if post.CoverImage != nil {
+		response := mediaDTO.ToMediaResponse(*post.CoverImage)
+		coverImage = &response
+	}
+
+	var authorName string
+	if post.Author != nil {
+		authorName = post.Author.FirstName + " " + post.Author.LastName
+	}
+
 	return PostResponse{
-		ID:         post.ID,
-		Title:      post.Title,
-		Excerpt:    post.Excerpt,
-		Content:    post.Content,
-		Categories: categoryIDs,
-		CreatedAt:  post.CreatedAt,
-		UpdatedAt:  post.UpdatedAt,
+		ID:            post.ID,
+		Title:         post.Title,
+		Slug:          post.Slug,
+		Excerpt:       post.Excerpt,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		ContentHTML:   post.ContentHTML,
+		AuthorID:      post.AuthorID,
+		Author:        authorName,
+		Status:        post.Status,
+		PublishedAt:   post.PublishedAt,
+		Categories:    categoryIDs,
+		Tags:          tags,
+		CoverImageID:  post.CoverImageID,
+		CoverImage:    coverImage,
+		Version:       post.Version,
+		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     post.UpdatedAt,
 	}
 }
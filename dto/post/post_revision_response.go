@@ -0,0 +1,83 @@
+package post
+
+import (
+	"go-blog/models/post"
+	"go-blog/utils/diff"
+	"time"
+)
+
+type PostRevisionSummaryResponse struct {
+	Number       int       `json:"number" example:"3"`
+	Title        string    `json:"title"`
+	Status       string    `json:"status" example:"PUBLISHED"`
+	EditorID     *uint     `json:"editor_id" example:"2"`
+	Editor       string    `json:"editor,omitempty" example:"Jane Doe"`
+	RestoredFrom *int      `json:"restored_from,omitempty" example:"1"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PostRevisionResponse struct {
+	PostRevisionSummaryResponse
+	PostID        uint     `json:"post_id" example:"12"`
+	Excerpt       string   `json:"excerpt"`
+	Content       string   `json:"content"`
+	ContentFormat string   `json:"content_format" example:"markdown"`
+	CategoryIDs   []uint   `json:"category_ids"`
+	Tags          []string `json:"tags"`
+	CoverImageID  *uint    `json:"cover_image_id" example:"12"`
+}
+
+// PostRevisionDiffResponse lists the changes from one revision to another. The title and excerpt are compared
+// word by word, the content line by line unless words are requested.
+type PostRevisionDiffResponse struct {
+	From              int          `json:"from" example:"1"`
+	To                int          `json:"to" example:"3"`
+	Title             []diff.Chunk `json:"title"`
+	Excerpt           []diff.Chunk `json:"excerpt"`
+	Content           []diff.Chunk `json:"content"`
+	StatusFrom        string       `json:"status_from" example:"DRAFT"`
+	StatusTo          string       `json:"status_to" example:"PUBLISHED"`
+	CategoriesAdded   []uint       `json:"categories_added"`
+	CategoriesRemoved []uint       `json:"categories_removed"`
+	TagsAdded         []string     `json:"tags_added"`
+	TagsRemoved       []string     `json:"tags_removed"`
+}
+
+func ToPostRevisionSummaryResponse(revision post.PostRevision) PostRevisionSummaryResponse {
+	var editorName string
+	if revision.Editor != nil {
+		editorName = revision.Editor.FirstName + " " + revision.Editor.LastName
+	}
+
+	return PostRevisionSummaryResponse{
+		Number:       revision.Number,
+		Title:        revision.Title,
+		Status:       revision.Status,
+		EditorID:     revision.EditorID,
+		Editor:       editorName,
+		RestoredFrom: revision.RestoredFrom,
+		CreatedAt:    revision.CreatedAt,
+	}
+}
+
+func ToPostRevisionResponse(revision post.PostRevision) PostRevisionResponse {
+	categoryIDs := revision.CategoryIDs
+	if categoryIDs == nil {
+		categoryIDs = []uint{}
+	}
+	tags := revision.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return PostRevisionResponse{
+		PostRevisionSummaryResponse: ToPostRevisionSummaryResponse(revision),
+		PostID:                      revision.PostID,
+		Excerpt:                     revision.Excerpt,
+		Content:                     revision.Content,
+		ContentFormat:               revision.ContentFormat,
+		CategoryIDs:                 categoryIDs,
+		Tags:                        tags,
+		CoverImageID:                revision.CoverImageID,
+	}
+}
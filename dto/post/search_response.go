@@ -0,0 +1,20 @@
+package post
+
+// PostHighlights holds the HTML snippets of a post where the search terms were found
+type PostHighlights struct {
+	Title   string `json:"title,omitempty" example:"Getting started with <mark>Go</mark>"`
+	Excerpt string `json:"excerpt,omitempty"`
+	Content string `json:"content,omitempty" example:"…how to install <mark>Go</mark> on your machine…"`
+}
+
+type PostSearchResult struct {
+	Post       PostResponse   `json:"post"`
+	Score      float64        `json:"score"`
+	Highlights PostHighlights `json:"highlights"`
+}
+
+type CommentSearchResult struct {
+	Comment   *CommentResponse `json:"comment"`
+	Score     float64          `json:"score"`
+	Highlight string           `json:"highlight,omitempty"`
+}
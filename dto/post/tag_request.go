@@ -0,0 +1,9 @@
+package post
+
+type TagRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"golang"`
+}
+
+type TagMergeRequest struct {
+	TargetID uint `json:"target_id" binding:"required" example:"2"`
+}
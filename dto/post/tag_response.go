@@ -0,0 +1,19 @@
+package post
+
+import postModel "go-blog/models/post"
+
+type TagResponse struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	PostCount int64  `json:"post_count"`
+}
+
+func ToTagResponse(tag postModel.Tag, postCount int64) TagResponse {
+	return TagResponse{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		Slug:      tag.Slug,
+		PostCount: postCount,
+	}
+}
@@ -0,0 +1,28 @@
+package user
+
+import (
+	"go-blog/models/auth"
+	"time"
+)
+
+type LoginAttemptResponse struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason" example:"INVALID_CREDENTIALS"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToLoginAttemptResponse(a auth.LoginAttempt) LoginAttemptResponse {
+	return LoginAttemptResponse{
+		ID:        a.ID,
+		Email:     a.Email,
+		IPAddress: a.IPAddress,
+		UserAgent: a.UserAgent,
+		Success:   a.Success,
+		Reason:    a.Reason,
+		CreatedAt: a.CreatedAt,
+	}
+}
@@ -0,0 +1,14 @@
+package user
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=AUTHOR CONTRIBUTOR ADMIN READER" example:"AUTHOR"`
+}
+
+type UpdateStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=ACTIVE INACTIVE BANNED PENDING" example:"INACTIVE"`
+	Reason string `json:"reason,omitempty" binding:"max=500" example:"Spam"`
+}
+
+type BanRequest struct {
+	Reason string `json:"reason" binding:"required,max=500" example:"Repeated spam in comments"`
+}
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"go-blog/models/user"
+	"time"
+)
+
+type AdminUserResponse struct {
+	ID          uint       `json:"id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	Status      string     `json:"status"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	BanReason   string     `json:"ban_reason,omitempty"`
+	BannedAt    *time.Time `json:"banned_at,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+func ToAdminUserResponse(u user.User) AdminUserResponse {
+	var deletedAt *time.Time
+	if u.DeletedAt.Valid {
+		deletedAt = &u.DeletedAt.Time
+	}
+
+	return AdminUserResponse{
+		ID:          u.ID,
+		Email:       u.Email,
+		Role:        u.Role,
+		Status:      u.Status,
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		BanReason:   u.BanReason,
+		BannedAt:    u.BannedAt,
+		LastLoginAt: u.LastLoginAt,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		DeletedAt:   deletedAt,
+	}
+}
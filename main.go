@@ -6,7 +6,10 @@ import (
 	"github.com/robfig/cron/v3"
 	_ "go-blog/docs"
 	"go-blog/services"
+	authService "go-blog/services/auth"
 	"go-blog/services/config"
+	"go-blog/services/mail"
+	"go-blog/services/storage"
 	"go-blog/utils/validators"
 	"log"
 )
@@ -26,6 +29,27 @@ func main() {
 func initializeConfiguration() {
 	config.Init()
 	config.InitJWTConfig()
+	if err := authService.InitSigningKeys(); err != nil {
+		log.Fatalf("Error while loading JWT signing keys: %v", err)
+	}
+	config.InitMailConfig()
+	if config.EmailVerificationSecret == "" {
+		log.Fatal("EMAIL_VERIFICATION_SECRET is required to sign email verification links")
+	}
+	config.InitTwoFactorConfig()
+	config.InitRateLimitConfig()
+	config.InitOIDCConfig()
+	config.InitStorageConfig()
+	config.InitFeedConfig()
+	mail.Init()
+	storage.Init()
+
+	if err := services.BackfillSlugs(); err != nil {
+		log.Printf("Error while generating missing slugs: %v", err)
+	}
+	if err := services.BackfillRenderedContent(); err != nil {
+		log.Printf("Error while rendering post and comment content: %v", err)
+	}
 }
 
 func setupCustomValidators() {
@@ -50,6 +74,16 @@ func setupCronJobs() {
 		panic("Error while adding cron task: " + err.Error())
 	}
 
+	_, err = c.AddFunc("@every 1m", publishScheduledPosts)
+	if err != nil {
+		panic("Error while adding cron task: " + err.Error())
+	}
+
+	_, err = c.AddFunc("@every 1h", cleanupExpiredTokens)
+	if err != nil {
+		panic("Error while adding cron task: " + err.Error())
+	}
+
 	c.Start()
 
 	// Immediate execution once at startup
@@ -65,6 +99,42 @@ func fetchNewsAsync() {
 	}
 }
 
+func publishScheduledPosts() {
+	if err := services.PublishScheduledPosts(); err != nil {
+		log.Printf("[CRON] Error while publishing scheduled posts: %v", err)
+	}
+}
+
+func cleanupExpiredTokens() {
+	deleted, err := authService.CleanupExpiredRefreshTokens()
+	if err != nil {
+		log.Printf("[CRON] Error while deleting expired refresh tokens: %v", err)
+	} else if deleted > 0 {
+		log.Printf("[CRON] Deleted %d expired refresh tokens", deleted)
+	}
+
+	deleted, err = authService.CleanupExpiredRevokedTokens()
+	if err != nil {
+		log.Printf("[CRON] Error while deleting expired revoked access tokens: %v", err)
+	} else if deleted > 0 {
+		log.Printf("[CRON] Deleted %d expired revoked access tokens", deleted)
+	}
+
+	deleted, err = authService.CleanupExpiredTwoFactorChallenges()
+	if err != nil {
+		log.Printf("[CRON] Error while deleting expired two-factor challenges: %v", err)
+	} else if deleted > 0 {
+		log.Printf("[CRON] Deleted %d expired two-factor challenges", deleted)
+	}
+
+	deleted, err = authService.CleanupExpiredOIDCLoginStates()
+	if err != nil {
+		log.Printf("[CRON] Error while deleting expired OpenID Connect logins: %v", err)
+	} else if deleted > 0 {
+		log.Printf("[CRON] Deleted %d expired OpenID Connect logins", deleted)
+	}
+}
+
 func startServer() {
 	router := services.InitRoutes()
 
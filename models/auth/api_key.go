@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"go-blog/models/user"
+	"strings"
+	"time"
+)
+
+const (
+	// APIKeyScopeRead allows the safe methods GET, HEAD and OPTIONS
+	APIKeyScopeRead = "read"
+	// APIKeyScopeWrite allows the methods changing data
+	APIKeyScopeWrite = "write"
+)
+
+// APIKey authenticates scripts as a user without a password. Only the SHA-256 hash of the key is stored,
+// with its first characters kept so users can tell their keys apart.
+type APIKey struct {
+	ID         uint      `gorm:"primaryKey"`
+	UserID     uint      `gorm:"not null;index"`
+	User       user.User `gorm:"foreignKey:UserID"`
+	Name       string    `gorm:"type:varchar(100);not null"`
+	Prefix     string    `gorm:"type:varchar(16);not null"`
+	KeyHash    string    `gorm:"type:char(64);not null;uniqueIndex"`
+	Scopes     string    `gorm:"type:varchar(100);not null"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	LastUsedAt *time.Time
+	LastUsedIP string `gorm:"type:varchar(45)"`
+	RevokedAt  *time.Time
+	// CreatedByID is the admin who created the key on behalf of the user, if any
+	CreatedByID *uint
+	CreatedAt   time.Time `gorm:"not null"`
+}
+
+// ScopeList returns the scopes granted to the key
+func (k APIKey) ScopeList() []string {
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope reports whether the key was granted the scope
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the key can still be used
+func (k APIKey) IsActive() bool {
+	return k.RevokedAt == nil && k.ExpiresAt.After(time.Now())
+}
@@ -1,8 +1,20 @@
 package auth
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"strconv"
+)
 
+// Claims of the access tokens. The subject is the user ID and the ID (jti) identifies the token so it can be revoked.
 type Claims struct {
-	Email string `json:"email"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// UserID returns the ID of the user the token was issued for, read from the subject
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 0)
+	return uint(id), err
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"go-blog/models/user"
+	"time"
+)
+
+// Identity links a user to an account of an OpenID Connect provider, identified by the provider and its subject
+type Identity struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	User      user.User `gorm:"foreignKey:UserID"`
+	Provider  string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_identities_provider_subject,priority:1"`
+	Subject   string    `gorm:"type:varchar(191);not null;uniqueIndex:idx_identities_provider_subject,priority:2"`
+	Email     string    `gorm:"type:varchar(191)"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// OIDCLoginState is an OpenID Connect login in progress, found back from the state parameter of the callback.
+// Only the hash of the state is stored; the nonce and the PKCE verifier never leave the server.
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"type:char(64);not null;uniqueIndex"`
+	Provider     string    `gorm:"type:varchar(50);not null"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `gorm:"not null"`
+}
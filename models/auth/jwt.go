@@ -1,12 +0,0 @@
-package auth
-
-import (
-	"github.com/golang-jwt/jwt/v5"
-)
-
-type CustomClaims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
-	jwt.RegisteredClaims
-}
@@ -0,0 +1,25 @@
+package auth
+
+import "time"
+
+// Reasons recorded with login attempts
+const (
+	LoginSucceeded            = "SUCCESS"
+	LoginInvalidCredentials   = "INVALID_CREDENTIALS"
+	LoginInvalidTwoFactorCode = "INVALID_TWO_FACTOR_CODE"
+	LoginTwoFactorChallenged  = "TWO_FACTOR_CHALLENGED"
+	LoginThrottled            = "THROTTLED"
+	LoginPending              = "PENDING" // reserved attempt whose credentials are being checked
+)
+
+// LoginAttempt is the audit entry of a login attempt, also used to throttle brute-force attacks per account and per IP
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    *uint     `gorm:"index"`
+	Email     string    `gorm:"type:varchar(191);not null;index:idx_login_attempts_email_created,priority:1"`
+	IPAddress string    `gorm:"type:varchar(45);not null;index:idx_login_attempts_ip_created,priority:1"`
+	UserAgent string    `gorm:"type:varchar(500)"`
+	Success   bool      `gorm:"not null"`
+	Reason    string    `gorm:"type:varchar(30);not null"`
+	CreatedAt time.Time `gorm:"not null;index:idx_login_attempts_email_created,priority:2;index:idx_login_attempts_ip_created,priority:2"`
+}
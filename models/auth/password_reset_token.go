@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"go-blog/models/user"
+	"time"
+)
+
+// PasswordResetToken is a single-use token sent by email to reset a password. Only its SHA-256 hash is stored.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	UserID    uint      `gorm:"not null;index"`
+	User      user.User `gorm:"foreignKey:UserID"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"not null"`
+}
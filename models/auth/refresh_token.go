@@ -5,11 +5,15 @@ import (
 	"time"
 )
 
+// RefreshToken is stored hashed. Every refresh rotates the token: the used one is marked as rotated and
+// a new one is issued in the same family, so presenting a rotated token again reveals a stolen token.
 type RefreshToken struct {
-	ID uint `gorm:"primaryKey"`
-	Token string `gorm:"not null;unique"`
-	UserID uint `gorm:"not null;index"`
-	User user.User `gorm:"foreignKey:UserID"`
-	ExpiresAt time.Time `gorm:"not null"`
+	ID        uint      `gorm:"primaryKey"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	FamilyID  string    `gorm:"type:varchar(64);not null;index"`
+	UserID    uint      `gorm:"not null;index"`
+	User      user.User `gorm:"foreignKey:UserID"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	RotatedAt *time.Time
 	CreatedAt time.Time `gorm:"not null"`
 }
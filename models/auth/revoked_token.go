@@ -0,0 +1,11 @@
+package auth
+
+import "time"
+
+// RevokedToken is an access token revoked before its expiry, identified by its jti.
+// Rows can be removed once ExpiresAt has passed since the token is rejected anyway.
+type RevokedToken struct {
+	JTI       string    `gorm:"type:varchar(64);primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"go-blog/models/user"
+	"time"
+)
+
+// Session is a login of a user on a device. Every refresh token issued for it shares its FamilyID.
+type Session struct {
+	ID         uint      `gorm:"primaryKey"`
+	FamilyID   string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	UserID     uint      `gorm:"not null;index"`
+	User       user.User `gorm:"foreignKey:UserID"`
+	UserAgent  string    `gorm:"type:varchar(500)"`
+	IPAddress  string    `gorm:"type:varchar(45)"`
+	LastUsedAt time.Time `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"not null"`
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"go-blog/models/user"
+	"time"
+)
+
+// TwoFactor holds the TOTP secret of a user. Two-factor authentication is only enforced once ConfirmedAt is set.
+type TwoFactor struct {
+	ID          uint      `gorm:"primaryKey"`
+	UserID      uint      `gorm:"not null;uniqueIndex"`
+	User        user.User `gorm:"foreignKey:UserID"`
+	Secret      string    `gorm:"type:varchar(64);not null"`
+	ConfirmedAt *time.Time
+	// LastUsedStep is the TOTP time step of the last accepted code, so a code can not be replayed
+	LastUsedStep int64
+	CreatedAt    time.Time `gorm:"not null"`
+	UpdatedAt    time.Time `gorm:"not null"`
+}
+
+// RecoveryCode is a one-time code replacing a TOTP code when the authenticator is lost. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	User      user.User `gorm:"foreignKey:UserID"`
+	CodeHash  string    `gorm:"type:char(64);not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TwoFactorChallenge is issued after a correct password for a user with two-factor authentication,
+// and exchanged for tokens with a valid code. Only its SHA-256 hash is stored.
+type TwoFactorChallenge struct {
+	ID        uint      `gorm:"primaryKey"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	UserID    uint      `gorm:"not null;index"`
+	User      user.User `gorm:"foreignKey:UserID"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"not null"`
+}
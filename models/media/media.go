@@ -0,0 +1,42 @@
+package media
+
+import (
+	"go-blog/models/user"
+	"strings"
+	"time"
+)
+
+// Media is an uploaded file of the media library. Images also have resized variants.
+type Media struct {
+	ID         uint       `gorm:"primaryKey"`
+	UploaderID *uint      `gorm:"index"`
+	Uploader   *user.User `gorm:"foreignKey:UploaderID"`
+	// FileName is the name of the file on the computer of the uploader
+	FileName string `gorm:"type:varchar(255);not null"`
+	// StorageKey is the key of the file in the storage, and URL its public URL at the time of the upload
+	StorageKey string `gorm:"type:varchar(191);uniqueIndex;not null"`
+	URL        string `gorm:"type:varchar(1000);not null"`
+	MimeType   string `gorm:"type:varchar(100);not null"`
+	Size       int64  `gorm:"not null"`
+	// Width and Height are 0 for files which are not images, and for images that can not be decoded (WebP)
+	Width     int            `gorm:"not null;default:0"`
+	Height    int            `gorm:"not null;default:0"`
+	AltText   string         `gorm:"type:varchar(500)"`
+	Variants  []MediaVariant `gorm:"foreignKey:MediaID"`
+	CreatedAt time.Time      `gorm:"not null"`
+	UpdatedAt time.Time      `gorm:"not null"`
+}
+
+// IsImage reports whether the media is an image
+func (m Media) IsImage() bool {
+	return strings.HasPrefix(m.MimeType, "image/")
+}
+
+// StorageKeys returns the keys of the file and of its variants
+func (m Media) StorageKeys() []string {
+	keys := []string{m.StorageKey}
+	for _, variant := range m.Variants {
+		keys = append(keys, variant.StorageKey)
+	}
+	return keys
+}
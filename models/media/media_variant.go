@@ -0,0 +1,22 @@
+package media
+
+const (
+	// VariantThumbnail is a square crop of the center of the image
+	VariantThumbnail = "thumbnail"
+	// VariantMedium and VariantLarge are the image scaled down to fit their maximum size
+	VariantMedium = "medium"
+	VariantLarge  = "large"
+)
+
+// MediaVariant is a resized copy of an image, generated on upload
+type MediaVariant struct {
+	ID         uint   `gorm:"primaryKey"`
+	MediaID    uint   `gorm:"not null;uniqueIndex:idx_media_variant_name"`
+	Name       string `gorm:"type:varchar(20);not null;uniqueIndex:idx_media_variant_name"`
+	StorageKey string `gorm:"type:varchar(191);uniqueIndex;not null"`
+	URL        string `gorm:"type:varchar(1000);not null"`
+	MimeType   string `gorm:"type:varchar(100);not null"`
+	Size       int64  `gorm:"not null"`
+	Width      int    `gorm:"not null"`
+	Height     int    `gorm:"not null"`
+}
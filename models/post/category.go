@@ -8,12 +8,22 @@ import (
 type Category struct {
 	ID          uint `gorm:"primaryKey"`
 	Name        string
+	Slug        string         `gorm:"type:varchar(191);uniqueIndex" json:"slug"`
 	Description string         `gorm:"not null"`
 	Posts       []Post         `gorm:"many2many:post_categories;" json:"posts"`
 	ParentID    *uint          `gorm:"index" json:"parent_id"`
 	Parent      *Category      `gorm:"foreignKey:ParentID" json:"parent"`
 	Children    []Category     `gorm:"foreignKey:ParentID" json:"children"`
+	Version     uint           `gorm:"not null;default:1"`
 	CreatedAt   time.Time      `gorm:"not null"`
 	UpdatedAt   time.Time      `gorm:"not null"`
 	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
+
+// BeforeCreate starts the version of new categories at 1
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	if c.Version == 0 {
+		c.Version = 1
+	}
+	return nil
+}
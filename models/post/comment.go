@@ -2,22 +2,53 @@ package post
 
 import (
 	"go-blog/models/user"
+	"go-blog/utils/content"
 	"gorm.io/gorm"
 	"time"
 )
 
 type Comment struct {
-	ID        uint           `gorm:"primaryKey"`
-	PostID    uint           `gorm:"index;not null"`
-	Post      Post           `gorm:"foreignKey:PostID"`
-	UserID    uint           `gorm:"index;not null"`
-	User      user.User      `gorm:"foreignKey:UserID"`
-	ParentID  *uint          `gorm:"index" json:"parent_id,omitempty"`
-	Parent    *Comment       `gorm:"foreignKey:ParentID" json:"-"`
-	Children  []Comment      `gorm:"foreignKey:ParentID" json:"children,omitempty"`
-	Status    string         `gorm:"type:ENUM('PENDING','APPROVED', 'REJECTED');default:'PENDING';not null"`
-	Content   string         `gorm:"not null"`
-	CreatedAt time.Time      `gorm:"not null"`
-	UpdatedAt time.Time      `gorm:"not null"`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID               uint           `gorm:"primaryKey"`
+	PostID           uint           `gorm:"index;not null"`
+	Post             Post           `gorm:"foreignKey:PostID"`
+	UserID           uint           `gorm:"index;not null"`
+	User             user.User      `gorm:"foreignKey:UserID"`
+	ParentID         *uint          `gorm:"index" json:"parent_id,omitempty"`
+	Parent           *Comment       `gorm:"foreignKey:ParentID" json:"-"`
+	Children         []Comment      `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+	Status           string         `gorm:"type:ENUM('PENDING','APPROVED', 'REJECTED');default:'PENDING';not null"`
+	Content          string         `gorm:"not null;index:idx_comments_fulltext,class:FULLTEXT"`
+	ContentFormat    string         `gorm:"type:varchar(10);default:'html';not null"`
+	ContentHTML      string         `gorm:"type:longtext"`
+	ModeratedByID    *uint          `gorm:"index" json:"moderated_by_id,omitempty"`
+	ModeratedBy      *user.User     `gorm:"foreignKey:ModeratedByID" json:"-"`
+	ModeratedAt      *time.Time     `json:"moderated_at,omitempty"`
+	ModerationReason string         `gorm:"type:varchar(500)" json:"moderation_reason,omitempty"`
+	Version          uint           `gorm:"not null;default:1"`
+	CreatedAt        time.Time      `gorm:"not null"`
+	UpdatedAt        time.Time      `gorm:"not null"`
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate starts the version of new comments at 1
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if c.Version == 0 {
+		c.Version = 1
+	}
+	return nil
+}
+
+// BeforeSave renders the content into sanitized HTML
+func (c *Comment) BeforeSave(tx *gorm.DB) error {
+	c.RenderContent()
+	return nil
+}
+
+// RenderContent renders the content into sanitized HTML, with the links of comments marked as user generated.
+// Comments without a format are HTML.
+func (c *Comment) RenderContent() {
+	if c.ContentFormat == "" {
+		c.ContentFormat = ContentFormatHTML
+	}
+	c.ContentHTML = renderContent(c.ContentFormat, c.Content, content.CommentPolicy)
 }
@@ -0,0 +1,16 @@
+package post
+
+import "go-blog/utils/content"
+
+const (
+	ContentFormatMarkdown = "markdown"
+	ContentFormatHTML     = "html"
+)
+
+// renderContent returns the safe HTML of the source, rendering it first when it is written in Markdown
+func renderContent(format, source string, policy *content.Policy) string {
+	if format == ContentFormatMarkdown {
+		source = content.Markdown(source)
+	}
+	return policy.Sanitize(source)
+}
@@ -1,17 +1,55 @@
 package post
 
 import (
+	"go-blog/models/media"
+	"go-blog/models/user"
+	"go-blog/utils/content"
 	"gorm.io/gorm"
 	"time"
 )
 
 type Post struct {
-	ID         uint           `gorm:"primaryKey"`
-	Title      string         `gorm:"not null"`
-	Excerpt    string         `gorm:"not null"`
-	Content    string         `gorm:"not null"`
-	Categories []Category     `gorm:"many2many:post_categories;" json:"categories"`
-	CreatedAt  time.Time      `gorm:"not null"`
-	UpdatedAt  time.Time      `gorm:"not null"`
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	ID            uint           `gorm:"primaryKey"`
+	Title         string         `gorm:"not null;index:idx_posts_fulltext,class:FULLTEXT"`
+	Slug          string         `gorm:"type:varchar(191);uniqueIndex" json:"slug"`
+	Excerpt       string         `gorm:"not null;index:idx_posts_fulltext,class:FULLTEXT"`
+	Content       string         `gorm:"not null;index:idx_posts_fulltext,class:FULLTEXT"`
+	ContentFormat string         `gorm:"type:varchar(10);default:'html';not null"`
+	ContentHTML   string         `gorm:"type:longtext"`
+	AuthorID      *uint          `gorm:"index" json:"author_id"`
+	Author        *user.User     `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	Status        string         `gorm:"type:ENUM('DRAFT','IN_REVIEW','SCHEDULED','PUBLISHED','ARCHIVED');default:'PUBLISHED';not null;index"`
+	PublishedAt   *time.Time     `gorm:"index" json:"published_at"`
+	Categories    []Category     `gorm:"many2many:post_categories;" json:"categories"`
+	Tags          []Tag          `gorm:"many2many:post_tags;" json:"tags"`
+	CoverImageID  *uint          `gorm:"index" json:"cover_image_id"`
+	CoverImage    *media.Media   `gorm:"foreignKey:CoverImageID" json:"cover_image"`
+	Media         []media.Media  `gorm:"many2many:post_media;" json:"media"`
+	Version       uint           `gorm:"not null;default:1"`
+	CreatedAt     time.Time      `gorm:"not null"`
+	UpdatedAt     time.Time      `gorm:"not null"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate starts the version of new posts at 1. The version is incremented by every change,
+// so that concurrent updates can be detected.
+func (p *Post) BeforeCreate(tx *gorm.DB) error {
+	if p.Version == 0 {
+		p.Version = 1
+	}
+	return nil
+}
+
+// BeforeSave renders the content into sanitized HTML, so that it never has to be rendered on reads
+func (p *Post) BeforeSave(tx *gorm.DB) error {
+	p.RenderContent()
+	return nil
+}
+
+// RenderContent renders the content into sanitized HTML. Posts without a format are HTML.
+func (p *Post) RenderContent() {
+	if p.ContentFormat == "" {
+		p.ContentFormat = ContentFormatHTML
+	}
+	p.ContentHTML = renderContent(p.ContentFormat, p.Content, content.PostPolicy)
 }
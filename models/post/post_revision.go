@@ -0,0 +1,27 @@
+package post
+
+import (
+	"go-blog/models/user"
+	"time"
+)
+
+// PostRevision is an immutable snapshot of a post, saved each time it is created, updated or restored.
+// Revisions are numbered from 1 for each post.
+type PostRevision struct {
+	ID            uint       `gorm:"primaryKey"`
+	PostID        uint       `gorm:"not null;uniqueIndex:idx_post_revision_number"`
+	Number        int        `gorm:"not null;uniqueIndex:idx_post_revision_number"`
+	Title         string     `gorm:"not null"`
+	Excerpt       string     `gorm:"type:text;not null"`
+	Content       string     `gorm:"type:longtext;not null"`
+	ContentFormat string     `gorm:"type:varchar(10);default:'html';not null"`
+	Status        string     `gorm:"type:varchar(20);not null"`
+	CategoryIDs   []uint     `gorm:"type:text;serializer:json"`
+	Tags          []string   `gorm:"type:text;serializer:json"`
+	EditorID      *uint      `gorm:"index"`
+	Editor        *user.User `gorm:"foreignKey:EditorID"`
+	CoverImageID  *uint
+	// RestoredFrom is the number of the revision this one restores, if any
+	RestoredFrom *int
+	CreatedAt    time.Time `gorm:"not null"`
+}
@@ -0,0 +1,9 @@
+package post
+
+const (
+	PostStatusDraft     = "DRAFT"
+	PostStatusInReview  = "IN_REVIEW"
+	PostStatusScheduled = "SCHEDULED"
+	PostStatusPublished = "PUBLISHED"
+	PostStatusArchived  = "ARCHIVED"
+)
@@ -0,0 +1,17 @@
+package post
+
+import "time"
+
+const (
+	SlugEntityPost     = "post"
+	SlugEntityCategory = "category"
+)
+
+// SlugRedirect keeps the previous slugs of a post or category so that old links keep working
+type SlugRedirect struct {
+	ID         uint      `gorm:"primaryKey"`
+	EntityType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_slug_redirect_entity_slug"`
+	OldSlug    string    `gorm:"type:varchar(191);not null;uniqueIndex:idx_slug_redirect_entity_slug"`
+	EntityID   uint      `gorm:"not null;index"`
+	CreatedAt  time.Time `gorm:"not null"`
+}
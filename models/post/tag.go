@@ -0,0 +1,12 @@
+package post
+
+import "time"
+
+type Tag struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"type:varchar(100);not null"`
+	Slug      string    `gorm:"type:varchar(191);uniqueIndex;not null" json:"slug"`
+	Posts     []Post    `gorm:"many2many:post_tags;" json:"posts"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
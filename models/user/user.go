@@ -6,15 +6,23 @@ import (
 )
 
 type User struct {
-	ID          uint           `gorm:"primaryKey"`
-	Email       string         `gorm:"unique"`
-	Password    string         `gorm:"not null"`
-	Role        string         `gorm:"type:ENUM('AUTHOR','CONTRIBUTOR','ADMIN','READER');default:'READER';not null"`
-	Status      string         `gorm:"type:ENUM('ACTIVE','INACTIVE','BANNED','PENDING');default:'ACTIVE';not null"`
-	FirstName   string         `gorm:"not null"`
-	LastName    string         `gorm:"not null"`
-	LastLoginAt *time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	ID                 uint   `gorm:"primaryKey"`
+	Email              string `gorm:"unique"`
+	Password           string `gorm:"not null"`
+	Role               string `gorm:"type:ENUM('AUTHOR','CONTRIBUTOR','ADMIN','READER');default:'READER';not null"`
+	Status             string `gorm:"type:ENUM('ACTIVE','INACTIVE','BANNED','PENDING');default:'ACTIVE';not null"`
+	FirstName          string `gorm:"not null"`
+	LastName           string `gorm:"not null"`
+	Bio                string `gorm:"type:text"`
+	AvatarURL          string `gorm:"type:varchar(500)"`
+	Website            string `gorm:"type:varchar(500)"`
+	LastLoginAt        *time.Time
+	EmailVerifiedAt    *time.Time
+	VerificationSentAt *time.Time
+	TwoFactorEnabled   bool   `gorm:"not null;default:false"`
+	BanReason          string `gorm:"type:varchar(500)"`
+	BannedAt           *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
 }
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-blog/dto/auth"
+	authModel "go-blog/models/auth"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	APIKeysPath    = "/me/api-keys"
+	APIKeyIDPath   = "/me/api-keys/:id"
+	APIKeyHeader   = "X-API-Key"
+	APIKeyNotFound = "API key not found"
+	TooManyAPIKeys = "Too many active API keys, revoke one first"
+
+	// Every API key starts with apiKeyPrefix, so it can be told apart from a JWT and spotted by secret scanners
+	apiKeyPrefix          = "gbk_"
+	apiKeyDisplayLength   = 12
+	defaultAPIKeyLifetime = 90 * 24 * time.Hour
+	maxActiveAPIKeys      = 20
+	// The last use of a key is recorded at most once per apiKeyUsageResolution
+	apiKeyUsageResolution = time.Minute
+)
+
+var (
+	errInvalidAPIKey  = errors.New("invalid, expired or revoked API key")
+	ErrTooManyAPIKeys = errors.New("too many active API keys")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// CreateMyAPIKey godoc
+// @Summary Create an API key
+// @Description Create a named, scoped and expiring API key for the currently authenticated user. The key is only returned in this response. Send it in the X-API-Key header, or as a Bearer token.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.CreateAPIKeyRequest true "Name, scopes and lifetime of the key"
+// @Success 201 {object} auth.CreatedAPIKeyResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/api-keys [post]
+func CreateMyAPIKey(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var input auth.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	key, apiKey, err := IssueAPIKey(userModel.ID, input, nil)
+	if errors.Is(err, ErrTooManyAPIKeys) {
+		ctx.AbortWithStatusJSON(http.StatusConflict, utils.NewErrorResponse(TooManyAPIKeys))
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to create API key"))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, auth.NewCreatedAPIKeyResponse(apiKey, key))
+}
+
+// GetMyAPIKeys godoc
+// @Summary List API keys
+// @Description List the API keys of the currently authenticated user, including the expired and revoked ones
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} auth.APIKeyResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/api-keys [get]
+func GetMyAPIKeys(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	apiKeys, err := ListAPIKeys(userModel.ID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error retrieving API keys"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.NewAPIKeyResponses(apiKeys))
+}
+
+// RevokeMyAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke one of the API keys of the currently authenticated user, effective immediately
+// @Tags Auth
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} auth.APIKeyRevokeResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/api-keys/{id} [delete]
+func RevokeMyAPIKey(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	err := RevokeAPIKey(userModel.ID, ctx.Param("id"))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, utils.NewErrorResponse(APIKeyNotFound))
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to revoke API key"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.APIKeyRevokeResponse{Message: "API key revoked successfully"})
+}
+
+// DenyAPIKeyMiddleWare refuses requests authenticated with an API key, on the routes managing credentials.
+// It must run after AuthenticationMiddleWare.
+func DenyAPIKeyMiddleWare(ctx *gin.Context) {
+	if _, ok := authUtils.GetAPIKeyID(ctx); ok {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, utils.NewErrorResponse("This route can not be used with an API key, log in instead"))
+		return
+	}
+	ctx.Next()
+}
+
+// IssueAPIKey creates an API key for the user and returns it with its record. createdByID is set when an admin
+// creates the key on behalf of the user.
+func IssueAPIKey(userID uint, input auth.CreateAPIKeyRequest, createdByID *uint) (string, authModel.APIKey, error) {
+	var active int64
+	if err := config.Db.Model(&authModel.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&active).Error; err != nil {
+		return "", authModel.APIKey{}, err
+	}
+	if active >= maxActiveAPIKeys {
+		return "", authModel.APIKey{}, ErrTooManyAPIKeys
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", authModel.APIKey{}, err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	lifetime := defaultAPIKeyLifetime
+	if input.ExpiresInDays > 0 {
+		lifetime = time.Duration(input.ExpiresInDays) * 24 * time.Hour
+	}
+
+	apiKey := authModel.APIKey{
+		UserID:      userID,
+		Name:        strings.TrimSpace(input.Name),
+		Prefix:      key[:apiKeyDisplayLength],
+		KeyHash:     authUtils.HashToken(key),
+		Scopes:      strings.Join(normalizeScopes(input.Scopes), ","),
+		ExpiresAt:   time.Now().Add(lifetime),
+		CreatedByID: createdByID,
+	}
+	if err := config.Db.Create(&apiKey).Error; err != nil {
+		return "", authModel.APIKey{}, err
+	}
+
+	return key, apiKey, nil
+}
+
+// ListAPIKeys returns the API keys of the user, including the expired and revoked ones, most recent first
+func ListAPIKeys(userID uint) ([]authModel.APIKey, error) {
+	var apiKeys []authModel.APIKey
+	err := config.Db.Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error
+	return apiKeys, err
+}
+
+// RevokeAPIKey revokes an API key of the user
+func RevokeAPIKey(userID uint, keyID string) error {
+	result := config.Db.Model(&authModel.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// apiKeyFromRequest returns the API key sent in the X-API-Key header, or as a Bearer token
+func apiKeyFromRequest(ctx *gin.Context) (string, bool) {
+	if key := strings.TrimSpace(ctx.GetHeader(APIKeyHeader)); key != "" {
+		return key, true
+	}
+	token, ok := authUtils.ExtractBearerToken(ctx.GetHeader("Authorization"))
+	if ok && strings.HasPrefix(token, apiKeyPrefix) {
+		return token, true
+	}
+	return "", false
+}
+
+// authenticateAPIKey returns the active user owning an active API key, and records the use of the key
+func authenticateAPIKey(ctx *gin.Context, key string) (user.User, authModel.APIKey, error) {
+	var apiKey authModel.APIKey
+	err := config.Db.Preload("User").Where("key_hash = ?", authUtils.HashToken(key)).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !apiKey.IsActive()) {
+		return user.User{}, apiKey, errInvalidAPIKey
+	}
+	if err != nil {
+		return user.User{}, apiKey, err
+	}
+	if apiKey.User.ID == 0 || apiKey.User.Status != string(user.StatusActive) {
+		return user.User{}, apiKey, errInactiveAccount
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyUsageResolution {
+		config.Db.Model(&apiKey).Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ctx.ClientIP()})
+	}
+
+	return apiKey.User, apiKey, nil
+}
+
+// apiKeyAllowsMethod reports whether the scopes of the key allow the HTTP method of the request
+func apiKeyAllowsMethod(apiKey authModel.APIKey, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return apiKey.HasScope(authModel.APIKeyScopeRead)
+	default:
+		return apiKey.HasScope(authModel.APIKeyScopeWrite)
+	}
+}
+
+// normalizeScopes removes duplicated scopes and sorts them
+func normalizeScopes(scopes []string) []string {
+	var normalized []string
+	for _, scope := range []string{authModel.APIKeyScopeRead, authModel.APIKeyScopeWrite} {
+		for _, s := range scopes {
+			if s == scope {
+				normalized = append(normalized, scope)
+				break
+			}
+		}
+	}
+	return normalized
+}
@@ -1,26 +1,34 @@
 package auth
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go-blog/dto/auth"
+	authModel "go-blog/models/auth"
 	"go-blog/models/user"
 	"go-blog/services/config"
 	"go-blog/utils"
 	authUtils "go-blog/utils/auth"
+	"gorm.io/gorm"
+	"log"
 	"net/http"
 	"strings"
 )
 
 var tokenService = NewTokenService()
 
+// accessTokenClaimsKey is the context key under which the authentication middleware stores the claims of the access token
+const accessTokenClaimsKey = "access_token_claims"
+
 // Register godoc
 // @Summary Register a new user
-// @Description Create a new user account
+// @Description Create a new user account. When email verification is enabled, the account stays PENDING until the link sent by email is opened and no tokens are returned.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param user body auth.RegisterRequest true "User registration payload"
 // @Success 200 {object} auth.RegisterResponse
+// @Success 202 {object} auth.VerificationResponse
 // @Failure 400 {object} utils.ValidationErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /v1/register [post]
@@ -32,12 +40,17 @@ func Register(ctx *gin.Context) {
 		return
 	}
 
+	// Deleted accounts keep their email, which stays unique until they are restored or purged
 	var existingUser user.User
-	config.Db.Where("email = ?", input.Email).First(&existingUser)
+	config.Db.Unscoped().Where("email = ?", input.Email).First(&existingUser)
 
 	if existingUser.ID != 0 {
+		message := "This email is already registered"
+		if existingUser.DeletedAt.Valid {
+			message = "This email belongs to a deleted account, contact an administrator to restore it"
+		}
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
-			{"field": "email", "message": "This email is already registered"},
+			{"field": "email", "message": message},
 		}))
 		return
 	}
@@ -57,39 +70,38 @@ func Register(ctx *gin.Context) {
 		Status:    string(user.StatusActive), // valeur par défaut
 	}
 
-	if err := config.Db.Create(&newUser).Error; err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error saving user to the database"))
-		return
+	if config.EmailVerificationEnabled {
+		newUser.Status = string(user.StatusPending)
 	}
 
-	accessToken, err := tokenService.GenerateAccessToken(newUser.Email)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate access token"))
+	if err := config.Db.Create(&newUser).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error saving user to the database"))
 		return
 	}
 
-	refreshToken, err := tokenService.GenerateRefreshToken(newUser.ID)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate refresh token"))
+	if config.EmailVerificationEnabled {
+		if err := sendVerificationEmail(&newUser); err != nil {
+			log.Printf("Verification email for %s not sent: %v", newUser.Email, err)
+		}
+		ctx.JSON(http.StatusAccepted, auth.VerificationResponse{Message: verificationSentMessage})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, auth.RegisterResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	})
+	respondWithTokens(ctx, newUser)
 }
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate user and return access and refresh tokens
+// @Description Authenticate user and return access and refresh tokens. Users with two-factor authentication get a challenge token instead, to send with a code to /v1/login/2fa. Repeated failures slow down the next attempts and temporarily lock the account, with a Retry-After header.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param user body auth.LoginRequest true "User login credentials" example({"email": "user@example.com", "password": "secret123"})
 // @Success 200 {object} auth.RegisterResponse
+// @Success 202 {object} auth.TwoFactorChallengeResponse
 // @Failure 400 {object} utils.ValidationErrorResponse
 // @Failure 401 {object} utils.ErrorResponse
+// @Failure 429 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /v1/login [post]
 func Login(ctx *gin.Context) {
@@ -100,32 +112,37 @@ func Login(ctx *gin.Context) {
 		return
 	}
 
-	userInfos, err := authUtils.ValidateCredentials(input.Email, input.Password)
+	attempt, wait, err := reserveLoginAttempt(ctx, input.Email, nil)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid credentials"))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error checking login attempts"))
+		return
+	}
+	if wait > 0 {
+		setRetryAfter(ctx, wait)
+		ctx.AbortWithStatusJSON(http.StatusTooManyRequests, utils.NewErrorResponse("Too many failed login attempts, please try again later"))
 		return
 	}
 
-	accessToken, err := tokenService.GenerateAccessToken(userInfos.Email)
+	userInfos, err := authUtils.ValidateCredentials(input.Email, input.Password)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate access token"))
+		completeLoginAttempt(attempt, nil, false, authModel.LoginInvalidCredentials)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid credentials"))
 		return
 	}
 
-	refreshToken, err := tokenService.GenerateRefreshToken(userInfos.ID)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate refresh token"))
+	if userInfos.TwoFactorEnabled {
+		completeLoginAttempt(attempt, &userInfos.ID, false, authModel.LoginTwoFactorChallenged)
+		startTwoFactorChallenge(ctx, *userInfos)
+		return
 	}
 
-	ctx.JSON(http.StatusOK, auth.RegisterResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	})
+	recordSuccessfulLogin(ctx, attempt, userInfos)
+	respondWithTokens(ctx, *userInfos)
 }
 
 // RefreshToken godoc
 // @Summary Refresh access token
-// @Description Generate new access token using refresh token
+// @Description Exchange a refresh token for a new access token and a new refresh token. The refresh token sent can not be used again: presenting it a second time logs out the whole session.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -143,32 +160,41 @@ func RefreshToken(ctx *gin.Context) {
 		return
 	}
 
-	refreshToken, err := tokenService.ValidateRefreshToken(input.RefreshToken)
-	if err != nil {
+	newRefreshToken, session, err := tokenService.RotateRefreshToken(input.RefreshToken, deviceFromContext(ctx))
+	if errors.Is(err, ErrRefreshTokenReused) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Refresh token already used, please log in again"))
+		return
+	}
+	if errors.Is(err, ErrInvalidRefreshToken) {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid refresh token"))
 		return
 	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to rotate refresh token"))
+		return
+	}
 
 	// Generate a new access token
 	userInfos := user.User{}
-	if err := config.Db.First(&userInfos, refreshToken.UserID).Error; err != nil {
+	if err := config.Db.First(&userInfos, session.UserID).Error; err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error retrieving user from database"))
 		return
 	}
-	accessToken, err := tokenService.GenerateAccessToken(userInfos.Email)
+	accessToken, err := tokenService.GenerateAccessToken(userInfos, session.ID)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate access token"))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, auth.RefreshResponse{
-		AccessToken: accessToken,
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
 	})
 }
 
 // Logout godoc
 // @Summary Logout user
-// @Description Invalidate refresh token and logout user
+// @Description Invalidate the refresh token and the access token used for the request, and logout user
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -191,6 +217,13 @@ func Logout(ctx *gin.Context) {
 		return
 	}
 
+	if claims, ok := accessTokenClaims(ctx); ok {
+		if err := tokenService.RevokeAccessToken(claims); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to revoke access token"))
+			return
+		}
+	}
+
 	ctx.JSON(http.StatusOK, auth.LogoutResponse{Message: "Logout successful"})
 }
 
@@ -221,8 +254,149 @@ func Me(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, auth.NewUserResponse(userModel))
 }
 
+// UpdateMe godoc
+// @Summary Update current user profile
+// @Description Update the name and profile fields of the currently authenticated user. Omitted fields are left unchanged.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.UpdateProfileRequest true "Profile fields to update"
+// @Success 200 {object} auth.UserResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me [patch]
+func UpdateMe(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var input auth.UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	// Only the sent fields are written, so that a concurrent change of the account by an admin is kept
+	changes := map[string]interface{}{}
+	if input.FirstName != nil {
+		changes["first_name"] = strings.TrimSpace(*input.FirstName)
+	}
+	if input.LastName != nil {
+		changes["last_name"] = strings.TrimSpace(*input.LastName)
+	}
+	if input.Bio != nil {
+		changes["bio"] = *input.Bio
+	}
+	if input.AvatarURL != nil {
+		changes["avatar_url"] = *input.AvatarURL
+	}
+	if input.Website != nil {
+		changes["website"] = *input.Website
+	}
+
+	if len(changes) > 0 {
+		if err := config.Db.Model(&user.User{}).Where("id = ?", userModel.ID).Updates(changes).Error; err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error updating profile"))
+			return
+		}
+	}
+	if err := config.Db.First(&userModel, userModel.ID).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error updating profile"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.NewUserResponse(userModel))
+}
+
+// ChangePassword godoc
+// @Summary Change current user password
+// @Description Change the password of the currently authenticated user after checking the current one. Every other session is logged out and the current one is kept.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} auth.ChangePasswordResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/password [post]
+func ChangePassword(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var input auth.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	if !authUtils.VerifyPassword(userModel.Password, input.CurrentPassword) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "current_password", "message": "Current password is incorrect"},
+		}))
+		return
+	}
+
+	if input.NewPassword == input.CurrentPassword {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "new_password", "message": "New password must be different from the current one"},
+		}))
+		return
+	}
+
+	hashedPassword, err := authUtils.HashPassword(input.NewPassword)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Unable to process password"))
+		return
+	}
+
+	if err := config.Db.Model(&user.User{}).Where("id = ?", userModel.ID).Update("password", hashedPassword).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error updating password"))
+		return
+	}
+
+	if err := tokenService.RevokeOtherUserTokens(userModel.ID, currentFamilyID(ctx, userModel.ID, input.RefreshToken)); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to revoke other sessions"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.ChangePasswordResponse{Message: "Password changed successfully"})
+}
+
 // AuthenticationMiddleWare is a middleware function that validates Authorization tokens and sets user claims in the Gin context.
+// API keys are accepted in place of access tokens, within their scopes.
 func AuthenticationMiddleWare(ctx *gin.Context) {
+	if key, ok := apiKeyFromRequest(ctx); ok {
+		userModel, apiKey, err := authenticateAPIKey(ctx, key)
+		if errors.Is(err, errInactiveAccount) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User account is not active"))
+			return
+		}
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid API key"))
+			return
+		}
+		if !apiKeyAllowsMethod(apiKey, ctx.Request.Method) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, utils.NewErrorResponse("Forbidden: insufficient API key scope"))
+			return
+		}
+
+		ctx.Set("user", userModel)
+		ctx.Set(authUtils.APIKeyIDKey, apiKey.ID)
+		ctx.Next()
+		return
+	}
+
 	authHeader := ctx.GetHeader("Authorization")
 	tokenString, ok := authUtils.ExtractBearerToken(authHeader)
 	if !ok {
@@ -236,16 +410,99 @@ func AuthenticationMiddleWare(ctx *gin.Context) {
 		return
 	}
 
-	var userModel user.User
-	if err := config.Db.Where("email = ?", claims.Email).First(&userModel).Error; err != nil {
+	row, err := loadAccessTokenUser(claims)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found"))
 		return
 	}
+	if err != nil || row.TokenRevoked {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid access token"))
+		return
+	}
+
+	if row.Status != string(user.StatusActive) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User account is not active"))
+		return
+	}
+
+	if !row.SessionActive {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Session has been revoked"))
+		return
+	}
 
-	ctx.Set("user", userModel)
+	ctx.Set("user", row.User)
+	ctx.Set(accessTokenClaimsKey, claims)
+	if claims.SessionID != 0 {
+		ctx.Set(authUtils.SessionIDKey, claims.SessionID)
+	}
+	ctx.Next()
+}
+
+// OptionalAuthenticationMiddleWare sets the user in the Gin context when a valid Authorization token is sent,
+// and lets anonymous requests through otherwise.
+func OptionalAuthenticationMiddleWare(ctx *gin.Context) {
+	if key, ok := apiKeyFromRequest(ctx); ok {
+		if userModel, apiKey, err := authenticateAPIKey(ctx, key); err == nil && apiKeyAllowsMethod(apiKey, ctx.Request.Method) {
+			ctx.Set("user", userModel)
+			ctx.Set(authUtils.APIKeyIDKey, apiKey.ID)
+		}
+		ctx.Next()
+		return
+	}
+
+	tokenString, ok := authUtils.ExtractBearerToken(ctx.GetHeader("Authorization"))
+	if !ok {
+		ctx.Next()
+		return
+	}
+
+	claims, err := tokenService.ParseAndValidateAccessToken(tokenString)
+	if err != nil {
+		ctx.Next()
+		return
+	}
+
+	if row, err := loadAccessTokenUser(claims); err == nil && !row.TokenRevoked &&
+		row.Status == string(user.StatusActive) && row.SessionActive {
+		ctx.Set("user", row.User)
+	}
 	ctx.Next()
 }
 
+// respondWithTokens opens a session for the user and responds with its access and refresh tokens
+func respondWithTokens(ctx *gin.Context, userModel user.User) {
+	refreshToken, session, err := tokenService.GenerateRefreshToken(userModel.ID, deviceFromContext(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate refresh token"))
+		return
+	}
+
+	accessToken, err := tokenService.GenerateAccessToken(userModel, session.ID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate access token"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.RegisterResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+func accessTokenClaims(ctx *gin.Context) (*authModel.Claims, bool) {
+	claimsAny, exists := ctx.Get(accessTokenClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := claimsAny.(*authModel.Claims)
+	return claims, ok
+}
+
+// RevokeUserSessions revokes every refresh token of the user
+func RevokeUserSessions(userID uint) error {
+	return tokenService.RevokeUserTokens(userID)
+}
+
 func AuthorizeRoles(allowedRoles ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		userAny, exists := ctx.Get("user")
@@ -270,3 +527,13 @@ func AuthorizeRoles(allowedRoles ...string) gin.HandlerFunc {
 		ctx.AbortWithStatusJSON(http.StatusForbidden, utils.NewErrorResponse("Forbidden: insufficient role"))
 	}
 }
+
+// CleanupExpiredRefreshTokens deletes expired refresh tokens, including the rotated ones kept for reuse detection
+func CleanupExpiredRefreshTokens() (int64, error) {
+	return tokenService.DeleteExpiredRefreshTokens()
+}
+
+// CleanupExpiredRevokedTokens deletes the denylist entries of expired access tokens
+func CleanupExpiredRevokedTokens() (int64, error) {
+	return tokenService.DeleteExpiredRevokedTokens()
+}
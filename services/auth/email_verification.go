@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-blog/dto/auth"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/services/mail"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// A verification email is sent at most once per verificationResendInterval to the same account
+	verificationResendInterval = time.Minute
+
+	verificationSentMessage = "A verification link has been sent to your email address"
+)
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Activate a PENDING account using the signed link received by email
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} auth.VerificationResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /v1/verify-email [post]
+func VerifyEmail(ctx *gin.Context) {
+	var input auth.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	invalidToken := utils.NewValidationErrorResponse([]map[string]string{
+		{"field": "token", "message": "Invalid or expired verification token"},
+	})
+
+	userID, email, err := authUtils.ParseVerificationToken(input.Token)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, invalidToken)
+		return
+	}
+
+	var userModel user.User
+	if err := config.Db.First(&userModel, userID).Error; err != nil || userModel.Email != email {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, invalidToken)
+		return
+	}
+	if userModel.EmailVerifiedAt != nil {
+		ctx.JSON(http.StatusOK, auth.VerificationResponse{Message: "Email address already verified"})
+		return
+	}
+	if userModel.Status != string(user.StatusPending) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, invalidToken)
+		return
+	}
+
+	now := time.Now()
+	if err := config.Db.Model(&userModel).Updates(map[string]interface{}{
+		"status":            string(user.StatusActive),
+		"email_verified_at": now,
+	}).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error verifying email address"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.VerificationResponse{Message: "Email address verified successfully"})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Send a new verification link to a PENDING account. The response is the same whether the account exists or not.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ResendVerificationRequest true "Email of the account"
+// @Success 200 {object} auth.VerificationResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Router /v1/resend-verification [post]
+func ResendVerification(ctx *gin.Context) {
+	var input auth.ResendVerificationRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	if err := resendVerificationEmail(strings.TrimSpace(input.Email)); err != nil {
+		log.Printf("Verification email for %s not resent: %v", input.Email, err)
+	}
+
+	ctx.JSON(http.StatusOK, auth.VerificationResponse{Message: "If a pending account exists for this email, a verification link has been sent"})
+}
+
+func resendVerificationEmail(email string) error {
+	var userModel user.User
+	if err := config.Db.Where("email = ?", email).First(&userModel).Error; err != nil {
+		return err
+	}
+	if userModel.Status != string(user.StatusPending) || userModel.EmailVerifiedAt != nil {
+		return errors.New("user is not waiting for verification")
+	}
+	if userModel.VerificationSentAt != nil && time.Since(*userModel.VerificationSentAt) < verificationResendInterval {
+		return errors.New("verification email sent too recently")
+	}
+	return sendVerificationEmail(&userModel)
+}
+
+// sendVerificationEmail mails a signed verification link to the user and records when it was sent
+func sendVerificationEmail(userModel *user.User) error {
+	token := authUtils.SignVerificationToken(userModel.ID, userModel.Email, time.Now().Add(config.EmailVerificationExpiration))
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(config.AppURL, "/"), url.QueryEscape(token))
+
+	err := mail.Default().Send(mail.Message{
+		To:      userModel.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n\nIf you did not create an account, you can ignore this email.",
+			userModel.FirstName, config.EmailVerificationExpiration, link),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	userModel.VerificationSentAt = &now
+	return config.Db.Model(userModel).Update("verification_sent_at", now).Error
+}
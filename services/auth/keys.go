@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go-blog/dto/auth"
+	"go-blog/services/config"
+	"math/big"
+	"net/http"
+	"os"
+)
+
+const JWKSPath = "/.well-known/jwks.json"
+
+// verificationKey is a public key accepted to verify access tokens, identified by the kid header of the tokens
+type verificationKey struct {
+	method jwt.SigningMethod
+	public crypto.PublicKey
+	jwk    auth.JWK
+}
+
+// signingKey signs the access tokens. Its kid is empty when signing with HS256 and the shared secret.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private interface{}
+}
+
+var (
+	currentSigningKey = signingKey{method: jwt.SigningMethodHS256}
+	verificationKeys  = map[string]verificationKey{}
+)
+
+// InitSigningKeys loads the access token signing key and the verification keys configured in PEM files.
+// Access tokens are signed with HS256 and the JWT secret when no private key is configured, which then must not be empty.
+func InitSigningKeys() error {
+	currentSigningKey = signingKey{method: jwt.SigningMethodHS256}
+	verificationKeys = map[string]verificationKey{}
+
+	if config.JWTPrivateKeyPath == "" {
+		if len(config.JWTVerificationKeyPaths) > 0 {
+			return errors.New("verification keys require a private key to sign access tokens")
+		}
+		if config.JWTSecretKey == "" {
+			return errors.New("JWT_SECRET_KEY is required when no private key is configured")
+		}
+		return nil
+	}
+
+	private, err := loadPrivateKey(config.JWTPrivateKeyPath)
+	if err != nil {
+		return err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("%s: unsupported private key", config.JWTPrivateKeyPath)
+	}
+	key, err := newVerificationKey(signer.Public())
+	if err != nil {
+		return fmt.Errorf("%s: %w", config.JWTPrivateKeyPath, err)
+	}
+	verificationKeys[key.jwk.Kid] = key
+	currentSigningKey = signingKey{id: key.jwk.Kid, method: key.method, private: private}
+
+	for _, path := range config.JWTVerificationKeyPaths {
+		public, err := loadPublicKey(path)
+		if err != nil {
+			return err
+		}
+		key, err := newVerificationKey(public)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		verificationKeys[key.jwk.Kid] = key
+	}
+
+	return nil
+}
+
+// JWKS godoc
+// @Summary Get the access token verification keys
+// @Description Public keys accepted to verify access tokens, as a JSON Web Key Set. The set is empty when tokens are signed with a shared secret.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} auth.JWKSResponse
+// @Router /.well-known/jwks.json [get]
+func JWKS(ctx *gin.Context) {
+	keys := make([]auth.JWK, 0, len(verificationKeys))
+	// The signing key comes first so clients trying keys in order find it immediately
+	if key, ok := verificationKeys[currentSigningKey.id]; ok {
+		keys = append(keys, key.jwk)
+	}
+	for kid, key := range verificationKeys {
+		if kid != currentSigningKey.id {
+			keys = append(keys, key.jwk)
+		}
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, auth.JWKSResponse{Keys: keys})
+}
+
+// signAccessToken signs the claims with the current key and sets its kid header
+func signAccessToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(currentSigningKey.method, claims)
+	if currentSigningKey.id == "" {
+		return token.SignedString([]byte(config.JWTSecretKey))
+	}
+	token.Header["kid"] = currentSigningKey.id
+	return token.SignedString(currentSigningKey.private)
+}
+
+// verificationKeyFunc returns the key matching the kid header of a token, refusing tokens signed with another algorithm than the key's
+func verificationKeyFunc(token *jwt.Token) (interface{}, error) {
+	if currentSigningKey.id == "" {
+		return []byte(config.JWTSecretKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+	}
+	return key.public, nil
+}
+
+// validSigningMethods lists the algorithms of the keys currently accepted
+func validSigningMethods() []string {
+	if currentSigningKey.id == "" {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+	seen := map[string]bool{}
+	var methods []string
+	for _, key := range verificationKeys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+func loadPrivateKey(path string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: expected an RSA or Ed25519 private key in PEM format", path)
+}
+
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: expected an RSA or Ed25519 public key in PEM format", path)
+}
+
+// newVerificationKey builds the JWK of a public key, using its RFC 7638 thumbprint as kid
+func newVerificationKey(public crypto.PublicKey) (verificationKey, error) {
+	var key verificationKey
+	var thumbprintInput interface{}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		n := base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		key = verificationKey{
+			method: jwt.SigningMethodRS256,
+			public: pub,
+			jwk:    auth.JWK{Kty: "RSA", Use: "sig", Alg: jwt.SigningMethodRS256.Alg(), N: n, E: e},
+		}
+		thumbprintInput = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{e, "RSA", n}
+	case ed25519.PublicKey:
+		x := base64.RawURLEncoding.EncodeToString(pub)
+		key = verificationKey{
+			method: jwt.SigningMethodEdDSA,
+			public: pub,
+			jwk:    auth.JWK{Kty: "OKP", Use: "sig", Alg: jwt.SigningMethodEdDSA.Alg(), Crv: "Ed25519", X: x},
+		}
+		thumbprintInput = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{"Ed25519", "OKP", x}
+	default:
+		return key, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	canonical, err := json.Marshal(thumbprintInput)
+	if err != nil {
+		return key, err
+	}
+	sum := sha256.Sum256(canonical)
+	key.jwk.Kid = base64.RawURLEncoding.EncodeToString(sum[:])
+	return key, nil
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	authModel "go-blog/models/auth"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Failed attempts are only counted within loginFailureWindow
+	loginFailureWindow = 15 * time.Minute
+
+	// An account gets freeAccountFailures attempts without delay, then the delay doubles after each failure,
+	// and the account is locked for loginLockoutDuration after maxAccountFailures consecutive failures
+	freeAccountFailures  = 3
+	maxAccountFailures   = 10
+	loginLockoutDuration = 15 * time.Minute
+
+	// An IP address gets more attempts since it may be shared by several users
+	freeIPFailures = 20
+)
+
+// countedLoginFailures are the failures slowing down the next attempts. Attempts still being checked count as failures.
+var countedLoginFailures = []string{authModel.LoginInvalidCredentials, authModel.LoginInvalidTwoFactorCode, authModel.LoginPending}
+
+// reserveLoginAttempt records a pending attempt before the credentials are checked, so that parallel attempts
+// slow each other down, and returns how long the client must wait before trying again, or zero.
+// A throttled attempt is recorded as such, otherwise the caller records its outcome with completeLoginAttempt.
+func reserveLoginAttempt(ctx *gin.Context, email string, userID *uint) (*authModel.LoginAttempt, time.Duration, error) {
+	device := deviceFromContext(ctx)
+	attempt := authModel.LoginAttempt{
+		UserID:    userID,
+		Email:     normalizeLoginEmail(email),
+		IPAddress: device.IPAddress,
+		UserAgent: device.UserAgent,
+		Success:   false,
+		Reason:    authModel.LoginPending,
+	}
+	if err := config.Db.Create(&attempt).Error; err != nil {
+		return nil, 0, err
+	}
+
+	wait, err := loginRetryAfter(attempt)
+	if err != nil {
+		return nil, 0, err
+	}
+	if wait > 0 {
+		completeLoginAttempt(&attempt, userID, false, authModel.LoginThrottled)
+	}
+	return &attempt, wait, nil
+}
+
+// completeLoginAttempt records the outcome of a reserved attempt. Failing to write it does not fail the login.
+func completeLoginAttempt(attempt *authModel.LoginAttempt, userID *uint, success bool, reason string) {
+	attempt.UserID = userID
+	attempt.Success = success
+	attempt.Reason = reason
+	err := config.Db.Model(&authModel.LoginAttempt{}).Where("id = ?", attempt.ID).Updates(map[string]interface{}{
+		"user_id": userID,
+		"success": success,
+		"reason":  reason,
+	}).Error
+	if err != nil {
+		log.Printf("Login attempt %d for %s not recorded: %v", attempt.ID, attempt.Email, err)
+	}
+}
+
+// loginRetryAfter returns how long the client making the reserved attempt must wait before trying to log in to the account again, or zero.
+// Only the attempts reserved before it count, so the first of parallel attempts is not delayed by the others.
+func loginRetryAfter(attempt authModel.LoginAttempt) (time.Duration, error) {
+	email := attempt.Email
+	since := time.Now().Add(-loginFailureWindow)
+
+	// Only the failures since the last successful login of the account count
+	var lastSuccess authModel.LoginAttempt
+	if err := config.Db.Where("email = ? AND success = ? AND created_at > ?", email, true, since).
+		Order("created_at DESC").Limit(1).Find(&lastSuccess).Error; err != nil {
+		return 0, err
+	}
+	accountSince := since
+	if lastSuccess.ID != 0 {
+		accountSince = lastSuccess.CreatedAt
+	}
+
+	accountFailures, lastAccountFailure, err := countLoginFailures("email = ?", email, accountSince, attempt.ID)
+	if err != nil {
+		return 0, err
+	}
+	ipFailures, lastIPFailure, err := countLoginFailures("ip_address = ?", attempt.IPAddress, since, attempt.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	if accountFailures >= maxAccountFailures {
+		wait = time.Until(lastAccountFailure.Add(loginLockoutDuration))
+	} else if delay := loginDelay(accountFailures, freeAccountFailures); delay > 0 {
+		wait = time.Until(lastAccountFailure.Add(delay))
+	}
+	if delay := loginDelay(ipFailures, freeIPFailures); delay > 0 {
+		if ipWait := time.Until(lastIPFailure.Add(delay)); ipWait > wait {
+			wait = ipWait
+		}
+	}
+
+	if wait < 0 {
+		return 0, nil
+	}
+	return wait, nil
+}
+
+func countLoginFailures(condition, value string, since time.Time, beforeID uint) (int, time.Time, error) {
+	var result struct {
+		Count int
+		Last  *time.Time
+	}
+	err := config.Db.Model(&authModel.LoginAttempt{}).
+		Select("COUNT(*) AS count, MAX(created_at) AS last").
+		Where(condition, value).
+		Where("success = ? AND reason IN ? AND created_at > ? AND id < ?", false, countedLoginFailures, since, beforeID).
+		Scan(&result).Error
+	if err != nil || result.Last == nil {
+		return 0, time.Time{}, err
+	}
+	return result.Count, *result.Last, nil
+}
+
+// loginDelay doubles the delay after each failure past the free ones, up to loginLockoutDuration
+func loginDelay(failures, free int) time.Duration {
+	if failures < free {
+		return 0
+	}
+	exponent := failures - free
+	if exponent > 30 {
+		return loginLockoutDuration
+	}
+	delay := time.Duration(math.Pow(2, float64(exponent))) * time.Second
+	if delay > loginLockoutDuration {
+		return loginLockoutDuration
+	}
+	return delay
+}
+
+// setRetryAfter sets the Retry-After header in whole seconds, rounded up
+func setRetryAfter(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
+// recordLoginAttempt writes the audit entry of a login attempt. Failing to write it does not fail the login.
+func recordLoginAttempt(ctx *gin.Context, email string, userID *uint, success bool, reason string) {
+	device := deviceFromContext(ctx)
+	attempt := authModel.LoginAttempt{
+		UserID:    userID,
+		Email:     normalizeLoginEmail(email),
+		IPAddress: device.IPAddress,
+		UserAgent: device.UserAgent,
+		Success:   success,
+		Reason:    reason,
+	}
+	if err := config.Db.Create(&attempt).Error; err != nil {
+		log.Printf("Login attempt for %s not recorded: %v", email, err)
+	}
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// recordSuccessfulLogin audits the login, completing the reserved attempt if any, and updates the last login date of the user
+func recordSuccessfulLogin(ctx *gin.Context, attempt *authModel.LoginAttempt, userModel *user.User) {
+	if attempt != nil {
+		completeLoginAttempt(attempt, &userModel.ID, true, authModel.LoginSucceeded)
+	} else {
+		recordLoginAttempt(ctx, userModel.Email, &userModel.ID, true, authModel.LoginSucceeded)
+	}
+
+	now := time.Now()
+	userModel.LastLoginAt = &now
+	if err := config.Db.Model(&user.User{}).Where("id = ?", userModel.ID).Update("last_login_at", now).Error; err != nil {
+		log.Printf("Last login date of user %d not updated: %v", userModel.ID, err)
+	}
+}
@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-blog/dto/auth"
+	authModel "go-blog/models/auth"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"go-blog/utils/oidc"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	OIDCLoginPath    = "/oidc/:provider/login"
+	OIDCCallbackPath = "/oidc/:provider/callback"
+	IdentitiesPath   = "/me/identities"
+
+	// The user has oidcLoginLifetime to log in at the provider
+	oidcLoginLifetime = 10 * time.Minute
+)
+
+var (
+	errUnverifiedEmail = errors.New("the identity provider did not return a verified email address")
+	errInactiveAccount = errors.New("user account is not active")
+	// errUnverifiedAccount is returned for an active account that never verified its email, e.g. registered while
+	// email verification was disabled: its owner has to log in with its password
+	errUnverifiedAccount = errors.New("the account with this email address has not verified it")
+)
+
+// OIDCLogin godoc
+// @Summary Start an OpenID Connect login
+// @Description Redirect to the authorization endpoint of the provider, using the authorization code flow with PKCE
+// @Tags Auth
+// @Param provider path string true "Provider name, as configured in OIDC_PROVIDERS"
+// @Success 302
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 502 {object} utils.ErrorResponse
+// @Router /v1/oidc/{provider}/login [get]
+func OIDCLogin(ctx *gin.Context) {
+	provider, ok := config.OIDCProviders[ctx.Param("provider")]
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, utils.NewErrorResponse("Unknown identity provider"))
+		return
+	}
+
+	metadata, err := oidc.Discover(provider)
+	if err != nil {
+		log.Printf("OIDC discovery of %s failed: %v", provider.Name, err)
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, utils.NewErrorResponse("Identity provider unavailable"))
+		return
+	}
+
+	state, err := authUtils.GenerateRandomToken(32)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to start login"))
+		return
+	}
+	nonce, err := oidc.NewCodeVerifier()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to start login"))
+		return
+	}
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to start login"))
+		return
+	}
+
+	loginState := authModel.OIDCLoginState{
+		StateHash:    authUtils.HashToken(state),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(oidcLoginLifetime),
+	}
+	if err := config.Db.Create(&loginState).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to start login"))
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, oidc.AuthCodeURL(metadata, provider, state, nonce, codeVerifier))
+}
+
+// OIDCCallback godoc
+// @Summary Complete an OpenID Connect login
+// @Description Exchange the authorization code returned by the provider for access and refresh tokens. The account is found by its linked identity, linked to an existing account with the same verified email, or created. A PENDING account whose email was never verified loses its password, two-factor authentication, sessions and API keys when linked, and other accounts that never verified their email are not linked.
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name, as configured in OIDC_PROVIDERS"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} auth.RegisterResponse
+// @Success 202 {object} auth.TwoFactorChallengeResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Failure 502 {object} utils.ErrorResponse
+// @Router /v1/oidc/{provider}/callback [get]
+func OIDCCallback(ctx *gin.Context) {
+	provider, ok := config.OIDCProviders[ctx.Param("provider")]
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, utils.NewErrorResponse("Unknown identity provider"))
+		return
+	}
+
+	if providerError := ctx.Query("error"); providerError != "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Login refused by the identity provider: "+providerError))
+		return
+	}
+
+	code, state := ctx.Query("code"), ctx.Query("state")
+	if code == "" || state == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("Missing code or state"))
+		return
+	}
+
+	// The state can only be used once
+	var loginState authModel.OIDCLoginState
+	err := config.Db.Where("state_hash = ? AND provider = ?", authUtils.HashToken(state), provider.Name).First(&loginState).Error
+	if err != nil || loginState.ExpiresAt.Before(time.Now()) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid or expired login state, please try again"))
+		return
+	}
+	if result := config.Db.Delete(&loginState); result.Error != nil || result.RowsAffected == 0 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid or expired login state, please try again"))
+		return
+	}
+
+	metadata, err := oidc.Discover(provider)
+	if err != nil {
+		log.Printf("OIDC discovery of %s failed: %v", provider.Name, err)
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, utils.NewErrorResponse("Identity provider unavailable"))
+		return
+	}
+
+	tokens, err := oidc.Exchange(metadata, provider, code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v", provider.Name, err)
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, utils.NewErrorResponse("Failed to exchange the authorization code"))
+		return
+	}
+
+	claims, err := oidc.VerifyIDToken(metadata, provider, tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC id_token from %s rejected: %v", provider.Name, err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid identity token"))
+		return
+	}
+
+	userModel, err := findOrCreateOIDCUser(provider.Name, claims)
+	if errors.Is(err, errUnverifiedEmail) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("The identity provider did not return a verified email address"))
+		return
+	}
+	if errors.Is(err, errInactiveAccount) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User account is not active"))
+		return
+	}
+	if errors.Is(err, errUnverifiedAccount) {
+		ctx.AbortWithStatusJSON(http.StatusConflict, utils.NewErrorResponse("An account already uses this email address, log in with its password first"))
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error retrieving user account"))
+		return
+	}
+
+	if userModel.TwoFactorEnabled {
+		recordLoginAttempt(ctx, userModel.Email, &userModel.ID, false, authModel.LoginTwoFactorChallenged)
+		startTwoFactorChallenge(ctx, userModel)
+		return
+	}
+
+	recordSuccessfulLogin(ctx, nil, &userModel)
+	respondWithTokens(ctx, userModel)
+}
+
+// GetMyIdentities godoc
+// @Summary List linked identities
+// @Description List the identity provider accounts linked to the currently authenticated user
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} auth.IdentityResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/identities [get]
+func GetMyIdentities(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var identities []authModel.Identity
+	if err := config.Db.Where("user_id = ?", userModel.ID).Order("created_at ASC").Find(&identities).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error retrieving identities"))
+		return
+	}
+
+	response := make([]auth.IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		response = append(response, auth.NewIdentityResponse(identity))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// findOrCreateOIDCUser returns the user linked to the provider account. Unknown provider accounts are linked to the
+// user with the same email, or to a new account, but only when the provider has verified the email.
+// See checkOIDCLink for existing accounts whose email was never verified.
+func findOrCreateOIDCUser(providerName string, claims *oidc.IDTokenClaims) (user.User, error) {
+	var userModel user.User
+
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		var identity authModel.Identity
+		err := tx.Preload("User").Where("provider = ? AND subject = ?", providerName, claims.Subject).First(&identity).Error
+		if err == nil {
+			userModel = identity.User
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		email := strings.TrimSpace(claims.Email)
+		if email == "" || !claims.EmailVerified {
+			return errUnverifiedEmail
+		}
+
+		err = tx.Where("email = ?", email).First(&userModel).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			userModel, err = newOIDCUser(email, claims)
+			if err == nil {
+				err = tx.Create(&userModel).Error
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		takeOver, err := checkOIDCLink(userModel)
+		if err != nil {
+			return err
+		}
+		if takeOver {
+			if err := secureUnverifiedAccount(tx, &userModel); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&authModel.Identity{
+			UserID:   userModel.ID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+			Email:    email,
+		}).Error
+	})
+	if err != nil {
+		return userModel, err
+	}
+
+	if userModel.Status != string(user.StatusActive) {
+		return userModel, errInactiveAccount
+	}
+	return userModel, nil
+}
+
+// checkOIDCLink decides whether an existing account can be linked to a provider account with the same verified email.
+// Accounts that verified their email are linked as is. A PENDING account was never usable, so it is handed over to
+// the owner of the email (takeOver), while other unverified accounts are refused with errUnverifiedAccount.
+func checkOIDCLink(userModel user.User) (takeOver bool, err error) {
+	if userModel.EmailVerifiedAt != nil {
+		return false, nil
+	}
+	if userModel.Status == string(user.StatusPending) {
+		return true, nil
+	}
+	return false, errUnverifiedAccount
+}
+
+// secureUnverifiedAccount hands a PENDING account over to the owner of its email, as proven by the provider:
+// the password, two-factor authentication, sessions and API keys set up by whoever registered it are dropped,
+// and the account is activated.
+func secureUnverifiedAccount(tx *gorm.DB, userModel *user.User) error {
+	password, err := authUtils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := authUtils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	userModel.Password = hashedPassword
+	userModel.EmailVerifiedAt = &now
+	userModel.TwoFactorEnabled = false
+	userModel.Status = string(user.StatusActive)
+	err = tx.Model(&user.User{}).Where("id = ?", userModel.ID).Updates(map[string]interface{}{
+		"password":           userModel.Password,
+		"email_verified_at":  userModel.EmailVerifiedAt,
+		"two_factor_enabled": false,
+		"status":             userModel.Status,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{&authModel.TwoFactor{}, &authModel.RecoveryCode{}, &authModel.TwoFactorChallenge{}} {
+		if err := tx.Where("user_id = ?", userModel.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := deleteUserSessions(tx, userModel.ID); err != nil {
+		return err
+	}
+	return tx.Model(&authModel.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userModel.ID).
+		Update("revoked_at", now).Error
+}
+
+// newOIDCUser builds an account for a provider account. Its password is random: the user logs in through the
+// provider, or sets a password with the password reset flow.
+func newOIDCUser(email string, claims *oidc.IDTokenClaims) (user.User, error) {
+	password, err := authUtils.GenerateRandomToken(32)
+	if err != nil {
+		return user.User{}, err
+	}
+	hashedPassword, err := authUtils.HashPassword(password)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(email, "@")
+	}
+
+	now := time.Now()
+	return user.User{
+		Email:           email,
+		Password:        hashedPassword,
+		FirstName:       firstName,
+		LastName:        lastName,
+		Role:            string(user.RoleReader),
+		Status:          string(user.StatusActive),
+		EmailVerifiedAt: &now,
+	}, nil
+}
+
+// CleanupExpiredOIDCLoginStates deletes the OpenID Connect logins that were never completed
+func CleanupExpiredOIDCLoginStates() (int64, error) {
+	result := config.Db.Where("expires_at < ?", time.Now()).Delete(&authModel.OIDCLoginState{})
+	return result.RowsAffected, result.Error
+}
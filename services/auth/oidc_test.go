@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/utils/oidc"
+	"go-blog/utils/oidc/oidctest"
+	"testing"
+	"time"
+)
+
+// loginWithStub signs in at a stub identity provider whose user has the given email, and returns the verified claims
+func loginWithStub(t *testing.T, email string) *oidc.IDTokenClaims {
+	t.Helper()
+	stub, err := oidctest.NewProvider("go-blog", "stub-user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stub.Close)
+	stub.Claims["email"] = email
+	stub.Claims["email_verified"] = true
+
+	provider := config.OIDCProvider{
+		Name:        "stub",
+		Issuer:      stub.Issuer(),
+		ClientID:    "go-blog",
+		RedirectURL: "http://localhost:8080/v1/oidc/stub/callback",
+		Scopes:      []string{"openid", "email"},
+	}
+	metadata, err := oidc.Discover(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, _ := oidc.NewCodeVerifier()
+	code, _, err := stub.Login(oidc.AuthCodeURL(metadata, provider, "state", "nonce", verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := oidc.Exchange(metadata, provider, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := oidc.VerifyIDToken(metadata, provider, tokens.IDToken, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestCheckOIDCLink(t *testing.T) {
+	claims := loginWithStub(t, "alice@example.com")
+	if !claims.EmailVerified {
+		t.Fatal("expected the stub provider to verify the email")
+	}
+
+	verifiedAt := time.Now()
+	tests := []struct {
+		name         string
+		account      user.User
+		wantTakeOver bool
+		wantErr      error
+	}{
+		{
+			name:    "active account registered while email verification was disabled",
+			account: user.User{Email: claims.Email, Status: string(user.StatusActive)},
+			wantErr: errUnverifiedAccount,
+		},
+		{
+			name:    "banned unverified account",
+			account: user.User{Email: claims.Email, Status: string(user.StatusBanned)},
+			wantErr: errUnverifiedAccount,
+		},
+		{
+			name:         "pending account",
+			account:      user.User{Email: claims.Email, Status: string(user.StatusPending)},
+			wantTakeOver: true,
+		},
+		{
+			name:    "verified account",
+			account: user.User{Email: claims.Email, Status: string(user.StatusActive), EmailVerifiedAt: &verifiedAt},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeOver, err := checkOIDCLink(tt.account)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if takeOver != tt.wantTakeOver {
+				t.Errorf("expected takeOver %v, got %v", tt.wantTakeOver, takeOver)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-blog/dto/auth"
+	authModel "go-blog/models/auth"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/services/mail"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// At most maxPasswordResetRequests reset emails are sent to an address per passwordResetWindow
+	maxPasswordResetRequests = 3
+	passwordResetWindow      = time.Hour
+
+	passwordResetMessage = "If an account exists for this email, a reset link has been sent"
+)
+
+// errInvalidResetToken is returned for an unknown, used or expired password reset token
+var errInvalidResetToken = errors.New("invalid or expired reset token")
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Send a single-use password reset link by email. The response is the same whether the account exists or not, and requests are rate limited per email address.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ForgotPasswordRequest true "Email of the account"
+// @Success 200 {object} auth.PasswordResetResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Router /v1/forgot-password [post]
+func ForgotPassword(ctx *gin.Context) {
+	var input auth.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	if err := sendPasswordResetEmail(strings.TrimSpace(input.Email)); err != nil {
+		log.Printf("Password reset for %s not sent: %v", input.Email, err)
+	}
+
+	ctx.JSON(http.StatusOK, auth.PasswordResetResponse{Message: passwordResetMessage})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using the token received by email. The token can only be used once and every session of the user is logged out.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} auth.PasswordResetResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /v1/reset-password [post]
+func ResetPassword(ctx *gin.Context) {
+	var input auth.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	hashedPassword, err := authUtils.HashPassword(input.NewPassword)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Unable to process password"))
+		return
+	}
+
+	err = config.Db.Transaction(func(tx *gorm.DB) error {
+		var resetToken authModel.PasswordResetToken
+		err := tx.Preload("User").Where("token_hash = ?", authUtils.HashToken(input.Token)).First(&resetToken).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && resetToken.User.ID == 0) {
+			return errInvalidResetToken
+		} else if err != nil {
+			return err
+		}
+
+		// Claim the token before changing anything, so that a token used or expired meanwhile changes nothing
+		now := time.Now()
+		claim := tx.Model(&authModel.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", resetToken.ID, now).
+			Update("used_at", now)
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected != 1 {
+			return errInvalidResetToken
+		}
+
+		if err := tx.Model(&user.User{}).Where("id = ?", resetToken.UserID).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		// Burn every other pending token of the user
+		if err := tx.Model(&authModel.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", resetToken.UserID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return deleteUserSessions(tx, resetToken.UserID)
+	})
+	if errors.Is(err, errInvalidResetToken) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "token", "message": "Invalid or expired reset token"},
+		}))
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error resetting password"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.PasswordResetResponse{Message: "Password reset successfully"})
+}
+
+func sendPasswordResetEmail(email string) error {
+	var userModel user.User
+	if err := config.Db.Where("email = ?", email).First(&userModel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("no account for this email")
+		}
+		return err
+	}
+	if userModel.Status != string(user.StatusActive) {
+		return errors.New("user is not active")
+	}
+
+	var recentRequests int64
+	if err := config.Db.Model(&authModel.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", userModel.ID, time.Now().Add(-passwordResetWindow)).
+		Count(&recentRequests).Error; err != nil {
+		return err
+	}
+	if recentRequests >= maxPasswordResetRequests {
+		return errors.New("too many reset requests")
+	}
+
+	token, err := authUtils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	resetToken := authModel.PasswordResetToken{
+		TokenHash: authUtils.HashToken(token),
+		UserID:    userModel.ID,
+		ExpiresAt: time.Now().Add(config.PasswordResetExpiration),
+	}
+	if err := config.Db.Create(&resetToken).Error; err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(config.AppURL, "/"), url.QueryEscape(token))
+	return mail.Default().Send(mail.Message{
+		To:      userModel.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for a password reset, you can ignore this email.",
+			userModel.FirstName, config.PasswordResetExpiration, link),
+	})
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-blog/dto/auth"
+	authModel "go-blog/models/auth"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"net/http"
+)
+
+const (
+	SessionsPath             = "/me/sessions"
+	SessionIDPath            = "/me/sessions/:id"
+	SessionsRevokeOthersPath = "/me/sessions/revoke-others"
+	SessionNotFound          = "Session not found"
+
+	maxUserAgentLength = 500
+)
+
+// DeviceInfo describes the client a session was opened or last used from
+type DeviceInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+func deviceFromContext(ctx *gin.Context) DeviceInfo {
+	userAgent := ctx.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return DeviceInfo{UserAgent: userAgent, IPAddress: ctx.ClientIP()}
+}
+
+// GetMySessions godoc
+// @Summary List current user sessions
+// @Description List the devices the currently authenticated user is logged in from, most recently used first
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} auth.SessionResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/sessions [get]
+func GetMySessions(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var sessions []authModel.Session
+	if err := config.Db.Where("user_id = ?", userModel.ID).Order("last_used_at DESC").Find(&sessions).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error retrieving sessions"))
+		return
+	}
+
+	currentSessionID, _ := authUtils.GetSessionID(ctx)
+	response := make([]auth.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, auth.NewSessionResponse(session, currentSessionID))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RevokeMySession godoc
+// @Summary Revoke a session
+// @Description Log out one of the devices of the currently authenticated user
+// @Tags Auth
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} auth.SessionRevokeResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/sessions/{id} [delete]
+func RevokeMySession(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var session authModel.Session
+	if err := config.Db.Where("id = ? AND user_id = ?", ctx.Param("id"), userModel.ID).First(&session).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, utils.NewErrorResponse(SessionNotFound))
+		return
+	}
+
+	if err := tokenService.RevokeTokenFamily(session.FamilyID); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to revoke session"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.SessionRevokeResponse{Message: "Session revoked successfully", Revoked: 1})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Log out every device of the currently authenticated user except the one making the request
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} auth.SessionRevokeResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/sessions/revoke-others [post]
+func RevokeOtherSessions(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	familyID := currentFamilyID(ctx, userModel.ID, "")
+	if familyID == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("Current session is unknown, please log in again"))
+		return
+	}
+
+	var revoked int64
+	if err := config.Db.Model(&authModel.Session{}).
+		Where("user_id = ? AND family_id <> ?", userModel.ID, familyID).
+		Count(&revoked).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error retrieving sessions"))
+		return
+	}
+
+	if err := tokenService.RevokeOtherUserTokens(userModel.ID, familyID); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to revoke other sessions"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.SessionRevokeResponse{Message: "Other sessions revoked successfully", Revoked: revoked})
+}
+
+// accessTokenUser is the user of an access token, with the state of the session and of the token
+type accessTokenUser struct {
+	user.User     `gorm:"embedded"`
+	SessionActive bool
+	TokenRevoked  bool
+}
+
+// loadAccessTokenUser loads the user of an access token, whether its session still exists and whether the token
+// is on the denylist, in a single query. The user is read from the database rather than built from the sub and
+// role claims: handlers need the whole user, and a ban or a role change has to apply before the token expires.
+// Tokens issued before sessions were tracked carry no session and are accepted until they expire.
+func loadAccessTokenUser(claims *authModel.Claims) (accessTokenUser, error) {
+	var row accessTokenUser
+	userID, err := claims.UserID()
+	if err != nil {
+		return row, err
+	}
+	err = config.Db.Model(&user.User{}).
+		Select(`users.*,
+			(? = 0 OR EXISTS (SELECT 1 FROM sessions WHERE sessions.id = ? AND sessions.user_id = users.id)) AS session_active,
+			EXISTS (SELECT 1 FROM revoked_tokens WHERE revoked_tokens.jti = ?) AS token_revoked`,
+			claims.SessionID, claims.SessionID, claims.ID).
+		Where("users.id = ?", userID).
+		Take(&row).Error
+	return row, err
+}
+
+// currentFamilyID returns the token family of the session making the request, found from the access token
+// or, for older access tokens, from the refresh token sent by the client
+func currentFamilyID(ctx *gin.Context, userID uint, refreshToken string) string {
+	if sessionID, ok := authUtils.GetSessionID(ctx); ok {
+		var session authModel.Session
+		if err := config.Db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err == nil {
+			return session.FamilyID
+		}
+	}
+	if refreshToken != "" {
+		var token authModel.RefreshToken
+		if err := config.Db.Where("token_hash = ? AND user_id = ?", authUtils.HashToken(refreshToken), userID).First(&token).Error; err == nil {
+			return token.FamilyID
+		}
+	}
+	return ""
+}
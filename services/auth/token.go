@@ -1,15 +1,25 @@
 package auth
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"go-blog/models/auth"
+	"go-blog/models/user"
 	"go-blog/services/config"
+	authUtils "go-blog/utils/auth"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
 	"time"
 )
 
+const refreshTokenLifetime = time.Hour * 24 * 7 // 7 days
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
 type TokenService struct{}
 
 func NewTokenService() *TokenService {
@@ -17,76 +27,246 @@ func NewTokenService() *TokenService {
 }
 
 
-func (ts *TokenService) GenerateAccessToken(email string) (string, error) {
-	return GenerateToken(email)
+func (ts *TokenService) GenerateAccessToken(userModel user.User, sessionID uint) (string, error) {
+	return GenerateToken(userModel, sessionID)
 }
 
-func (ts *TokenService) GenerateRefreshToken(userID uint) (string, error) {
-	tokenString, err := ts.generateRandomString(32)
+// GenerateRefreshToken opens a new session for the device and issues the first refresh token of its family
+func (ts *TokenService) GenerateRefreshToken(userID uint, device DeviceInfo) (string, *auth.Session, error) {
+	familyID, err := ts.generateRandomString(32)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	refreshToken := auth.RefreshToken{
-		Token:     tokenString,
-		UserID:    userID,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * 7), // 7 days
+	var tokenString string
+	session := auth.Session{
+		FamilyID:   authUtils.HashToken(familyID),
+		UserID:     userID,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		LastUsedAt: time.Now(),
+	}
+	err = config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		tokenString, err = ts.issueRefreshToken(tx, userID, session.FamilyID)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
 	}
 
-	if err := config.Db.Create(&refreshToken).Error; err != nil {
-		return "", err
+	return tokenString, &session, nil
+}
+
+// RotateRefreshToken exchanges a valid refresh token for a new one of the same family and records the device using the session.
+// Presenting a token that was already rotated revokes the whole session and returns ErrRefreshTokenReused.
+func (ts *TokenService) RotateRefreshToken(tokenString string, device DeviceInfo) (string, *auth.Session, error) {
+	var newToken string
+	var refreshToken auth.RefreshToken
+	var session auth.Session
+
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", authUtils.HashToken(tokenString)).First(&refreshToken).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+		if refreshToken.RotatedAt != nil {
+			return ErrRefreshTokenReused
+		}
+		if refreshToken.ExpiresAt.Before(time.Now()) {
+			return ErrInvalidRefreshToken
+		}
+
+		// Only one concurrent request can rotate the token, the others see it as reused
+		result := tx.Model(&auth.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL", refreshToken.ID).
+			Update("rotated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		// Tokens issued before sessions existed get their session on first use
+		now := time.Now()
+		if err := tx.Where(auth.Session{FamilyID: refreshToken.FamilyID}).
+			Attrs(auth.Session{UserID: refreshToken.UserID, LastUsedAt: now}).
+			FirstOrCreate(&session).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&session).Updates(map[string]interface{}{
+			"user_agent":   device.UserAgent,
+			"ip_address":   device.IPAddress,
+			"last_used_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		newToken, err = ts.issueRefreshToken(tx, refreshToken.UserID, refreshToken.FamilyID)
+		return err
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := ts.RevokeTokenFamily(refreshToken.FamilyID); revokeErr != nil {
+			return "", nil, revokeErr
+		}
+	}
+	if err != nil {
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return newToken, &session, nil
 }
 
-func (ts *TokenService) ValidateRefreshToken(tokenString string) (*auth.RefreshToken, error) {
+// DeleteRefreshToken revokes the session the token belongs to
+func (ts *TokenService) DeleteRefreshToken(tokenString string) error {
 	var refreshToken auth.RefreshToken
-	err := config.Db.Where("token = ?", tokenString).First(&refreshToken).Error
+	err := config.Db.Where("token_hash = ?", authUtils.HashToken(tokenString)).First(&refreshToken).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
 	if err != nil {
-		return nil, err
+		return err
+	}
+	return ts.RevokeTokenFamily(refreshToken.FamilyID)
+}
+
+// RevokeTokenFamily deletes a session and every refresh token of its family, current and rotated ones
+func (ts *TokenService) RevokeTokenFamily(familyID string) error {
+	return config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("family_id = ?", familyID).Delete(&auth.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("family_id = ?", familyID).Delete(&auth.Session{}).Error
+	})
+}
+
+// RevokeUserTokens deletes every session and refresh token of the user, logging them out everywhere
+func (ts *TokenService) RevokeUserTokens(userID uint) error {
+	return config.Db.Transaction(func(tx *gorm.DB) error {
+		return deleteUserSessions(tx, userID)
+	})
+}
+
+// RevokeOtherUserTokens deletes every session and refresh token of the user except the session of the given family
+func (ts *TokenService) RevokeOtherUserTokens(userID uint, keepFamilyID string) error {
+	return config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND family_id <> ?", userID, keepFamilyID).Delete(&auth.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND family_id <> ?", userID, keepFamilyID).Delete(&auth.Session{}).Error
+	})
+}
+
+// DeleteExpiredRefreshTokens removes expired refresh tokens and the sessions left without any token,
+// and returns how many tokens were deleted
+func (ts *TokenService) DeleteExpiredRefreshTokens() (int64, error) {
+	result := config.Db.Where("expires_at < ?", time.Now()).Delete(&auth.RefreshToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	err := config.Db.Where("NOT EXISTS (SELECT 1 FROM refresh_tokens rt WHERE rt.family_id = sessions.family_id)").
+		Delete(&auth.Session{}).Error
+	return result.RowsAffected, err
+}
+
+func (ts *TokenService) issueRefreshToken(db *gorm.DB, userID uint, familyID string) (string, error) {
+	tokenString, err := ts.generateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	refreshToken := auth.RefreshToken{
+		TokenHash: authUtils.HashToken(tokenString),
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(refreshTokenLifetime),
 	}
 
-	if refreshToken.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("token expired")
+	if err := db.Create(&refreshToken).Error; err != nil {
+		return "", err
 	}
 
-	return &refreshToken, nil
+	return tokenString, nil
 }
 
-func (ts *TokenService) DeleteRefreshToken(tokenString string) error {
-	return config.Db.Where("token = ?", tokenString).Delete(&auth.RefreshToken{}).Error
+// deleteUserSessions deletes every session and refresh token of the user within the given transaction
+func deleteUserSessions(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&auth.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&auth.Session{}).Error
 }
 
+// ParseAndValidateAccessToken checks the signature, algorithm, issuer, audience and dates of an access token.
+// Whether it was revoked is checked by loadAccessTokenUser, in the query loading its user.
 func (ts *TokenService) ParseAndValidateAccessToken(tokenString string) (*auth.Claims, error) {
 	claims := &auth.Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.JWTSecretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKeyFunc,
+		jwt.WithValidMethods(validSigningMethods()),
+		jwt.WithIssuer(config.JWTIssuer),
+		jwt.WithAudience(config.JWTAudience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil || !token.Valid {
 		return nil, err
 	}
+	if claims.ID == "" {
+		return nil, errors.New("token has no jti")
+	}
+
 	return claims, nil
 }
 
+// RevokeAccessToken adds the jti of an access token to the denylist until the token expires
+func (ts *TokenService) RevokeAccessToken(claims *auth.Claims) error {
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Before(time.Now()) {
+		return nil
+	}
+	return config.Db.Clauses(clause.OnConflict{DoNothing: true}).Create(&auth.RevokedToken{
+		JTI:       claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}).Error
+}
+
+// DeleteExpiredRevokedTokens removes the denylist entries of access tokens that have expired anyway
+func (ts *TokenService) DeleteExpiredRevokedTokens() (int64, error) {
+	result := config.Db.Where("expires_at < ?", time.Now()).Delete(&auth.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
+
 func (ts *TokenService) generateRandomString(n int) (string, error) {
-	b := make([]byte, n)
-	if _, err := rand.Read(b); err != nil {
+	return authUtils.GenerateRandomToken(n)
+}
+
+func GenerateToken(userModel user.User, sessionID uint) (string, error) {
+	jti, err := authUtils.GenerateRandomToken(16)
+	if err != nil {
 		return "", err
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
-}
 
-func GenerateToken(email string) (string, error) {
-	expirationTime := time.Now().Add(config.TokenExpiration)
+	now := time.Now()
+	expirationTime := now.Add(config.TokenExpiration)
 
 	claims := &auth.Claims{
-		Email: email,
+		Email:     userModel.Email,
+		Role:      userModel.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.FormatUint(uint64(userModel.ID), 10),
+			Issuer:    config.JWTIssuer,
+			Audience:  jwt.ClaimStrings{config.JWTAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.JWTSecretKey))
+	return signAccessToken(claims)
 }
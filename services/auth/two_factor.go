@@ -0,0 +1,459 @@
+package auth
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-blog/dto/auth"
+	authModel "go-blog/models/auth"
+	"go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+const (
+	TwoFactorSetupPath         = "/me/2fa/setup"
+	TwoFactorConfirmPath       = "/me/2fa/confirm"
+	TwoFactorDisablePath       = "/me/2fa/disable"
+	TwoFactorRecoveryCodesPath = "/me/2fa/recovery-codes"
+	TwoFactorLoginPath         = "/login/2fa"
+
+	recoveryCodeCount = 10
+
+	twoFactorChallengeLifetime = 5 * time.Minute
+	// A challenge is burnt after maxTwoFactorAttempts wrong codes, so codes can not be brute-forced
+	maxTwoFactorAttempts = 5
+
+	invalidTwoFactorCode = "Invalid two-factor authentication code"
+)
+
+// SetupTwoFactor godoc
+// @Summary Start two-factor authentication enrollment
+// @Description Generate a new TOTP secret for the currently authenticated user. Two-factor authentication is only enabled once a code is confirmed.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} auth.TwoFactorSetupResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/2fa/setup [post]
+func SetupTwoFactor(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+	if userModel.TwoFactorEnabled {
+		ctx.AbortWithStatusJSON(http.StatusConflict, utils.NewErrorResponse("Two-factor authentication is already enabled"))
+		return
+	}
+
+	secret, err := authUtils.GenerateTOTPSecret()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate secret"))
+		return
+	}
+
+	twoFactor := authModel.TwoFactor{UserID: userModel.ID}
+	if err := config.Db.Where(authModel.TwoFactor{UserID: userModel.ID}).
+		Assign(authModel.TwoFactor{Secret: secret}).
+		FirstOrCreate(&twoFactor).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error saving two-factor secret"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.TwoFactorSetupResponse{
+		Secret:     secret,
+		OtpauthURI: authUtils.TOTPURI(config.TwoFactorIssuer, userModel.Email, secret),
+	})
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Enable two-factor authentication
+// @Description Confirm the enrollment with a code from the authenticator app. The recovery codes are only returned once.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} auth.RecoveryCodesResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/2fa/confirm [post]
+func ConfirmTwoFactor(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var input auth.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	if userModel.TwoFactorEnabled {
+		ctx.AbortWithStatusJSON(http.StatusConflict, utils.NewErrorResponse("Two-factor authentication is already enabled"))
+		return
+	}
+
+	var twoFactor authModel.TwoFactor
+	if err := config.Db.Where("user_id = ?", userModel.ID).First(&twoFactor).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("Two-factor authentication setup has not been started"))
+		return
+	}
+
+	step, valid := authUtils.ValidateTOTP(twoFactor.Secret, input.Code, time.Now())
+	if !valid {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "code", "message": invalidTwoFactorCode},
+		}))
+		return
+	}
+
+	var codes []string
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&twoFactor).Updates(map[string]interface{}{"confirmed_at": now, "last_used_step": step}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user.User{}).Where("id = ?", userModel.ID).Update("two_factor_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userModel.ID)
+		return err
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error enabling two-factor authentication"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.RecoveryCodesResponse{Message: "Two-factor authentication enabled", RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication after checking the password and a TOTP or recovery code. Not allowed for roles required to use two-factor authentication.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorDisableRequest true "Password and code"
+// @Success 200 {object} auth.TwoFactorDisableResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/2fa/disable [post]
+func DisableTwoFactor(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var input auth.TwoFactorDisableRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	if !userModel.TwoFactorEnabled {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("Two-factor authentication is not enabled"))
+		return
+	}
+	if config.IsTwoFactorRequired(userModel.Role) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, utils.NewErrorResponse("Two-factor authentication is required for your role"))
+		return
+	}
+	if !authUtils.VerifyPassword(userModel.Password, input.Password) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "password", "message": "Password is incorrect"},
+		}))
+		return
+	}
+
+	valid, err := verifySecondFactor(userModel.ID, input.Code)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error checking two-factor authentication code"))
+		return
+	}
+	if !valid {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "code", "message": invalidTwoFactorCode},
+		}))
+		return
+	}
+
+	err = config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userModel.ID).Delete(&authModel.TwoFactor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userModel.ID).Delete(&authModel.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user.User{}).Where("id = ?", userModel.ID).Update("two_factor_enabled", false).Error
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error disabling two-factor authentication"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.TwoFactorDisableResponse{Message: "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace every recovery code of the currently authenticated user after checking a TOTP code
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} auth.RecoveryCodesResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/me/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var input auth.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	if !userModel.TwoFactorEnabled {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewErrorResponse("Two-factor authentication is not enabled"))
+		return
+	}
+
+	valid, err := verifyTOTP(config.Db, userModel.ID, input.Code)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error checking two-factor authentication code"))
+		return
+	}
+	if !valid {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "code", "message": invalidTwoFactorCode},
+		}))
+		return
+	}
+
+	var codes []string
+	err = config.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userModel.ID)
+		return err
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error generating recovery codes"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, auth.RecoveryCodesResponse{Message: "Recovery codes regenerated", RecoveryCodes: codes})
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the challenge token returned by /v1/login and a TOTP or recovery code for access and refresh tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} auth.RegisterResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 429 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /v1/login/2fa [post]
+func LoginTwoFactor(ctx *gin.Context) {
+	var input auth.TwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		errs := utils.FormatValidationError(err, input)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, utils.NewValidationErrorResponse(errs))
+		return
+	}
+
+	var challenge authModel.TwoFactorChallenge
+	err := config.Db.Preload("User").Where("token_hash = ?", authUtils.HashToken(input.ChallengeToken)).First(&challenge).Error
+	if err != nil || challenge.ExpiresAt.Before(time.Now()) || challenge.Attempts >= maxTwoFactorAttempts {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid or expired challenge, please log in again"))
+		return
+	}
+	if challenge.User.Status != string(user.StatusActive) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("User account is not active"))
+		return
+	}
+
+	attempt, wait, err := reserveLoginAttempt(ctx, challenge.User.Email, &challenge.UserID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error checking login attempts"))
+		return
+	}
+	if wait > 0 {
+		setRetryAfter(ctx, wait)
+		ctx.AbortWithStatusJSON(http.StatusTooManyRequests, utils.NewErrorResponse("Too many failed login attempts, please try again later"))
+		return
+	}
+
+	// The attempt is claimed before checking the code, so parallel requests can not try more than maxTwoFactorAttempts codes
+	claim := config.Db.Model(&authModel.TwoFactorChallenge{}).
+		Where("id = ? AND attempts < ?", challenge.ID, maxTwoFactorAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if claim.Error != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error checking two-factor authentication code"))
+		return
+	}
+	if claim.RowsAffected == 0 {
+		completeLoginAttempt(attempt, &challenge.UserID, false, authModel.LoginInvalidTwoFactorCode)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid or expired challenge, please log in again"))
+		return
+	}
+
+	valid, err := verifySecondFactor(challenge.UserID, input.Code)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error checking two-factor authentication code"))
+		return
+	}
+	if !valid {
+		completeLoginAttempt(attempt, &challenge.UserID, false, authModel.LoginInvalidTwoFactorCode)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse(invalidTwoFactorCode))
+		return
+	}
+
+	// Only the request deleting the challenge completes the login
+	deleted := config.Db.Delete(&challenge)
+	if deleted.Error != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Error completing login"))
+		return
+	}
+	if deleted.RowsAffected == 0 {
+		completeLoginAttempt(attempt, &challenge.UserID, false, authModel.LoginInvalidTwoFactorCode)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorResponse("Invalid or expired challenge, please log in again"))
+		return
+	}
+
+	recordSuccessfulLogin(ctx, attempt, &challenge.User)
+
+	respondWithTokens(ctx, challenge.User)
+}
+
+// TwoFactorPolicyMiddleWare refuses requests from users whose role requires two-factor authentication
+// until they have enabled it. It must run after AuthenticationMiddleWare.
+func TwoFactorPolicyMiddleWare(ctx *gin.Context) {
+	userModel, ok := authUtils.GetAuthenticatedUser(ctx)
+	if ok && !userModel.TwoFactorEnabled && config.IsTwoFactorRequired(userModel.Role) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, utils.NewErrorResponse("Two-factor authentication must be enabled for your role, set it up with "+TwoFactorSetupPath))
+		return
+	}
+	ctx.Next()
+}
+
+// CleanupExpiredTwoFactorChallenges deletes the login challenges that can no longer be used
+func CleanupExpiredTwoFactorChallenges() (int64, error) {
+	result := config.Db.Where("expires_at < ?", time.Now()).Delete(&authModel.TwoFactorChallenge{})
+	return result.RowsAffected, result.Error
+}
+
+// startTwoFactorChallenge responds to a correct password with a challenge token to exchange with a code
+func startTwoFactorChallenge(ctx *gin.Context, userModel user.User) {
+	token, err := authUtils.GenerateRandomToken(32)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate challenge"))
+		return
+	}
+
+	challenge := authModel.TwoFactorChallenge{
+		TokenHash: authUtils.HashToken(token),
+		UserID:    userModel.ID,
+		ExpiresAt: time.Now().Add(twoFactorChallengeLifetime),
+	}
+	if err := config.Db.Create(&challenge).Error; err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to generate challenge"))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, auth.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(twoFactorChallengeLifetime.Seconds()),
+	})
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code, which is then burnt
+func verifySecondFactor(userID uint, code string) (bool, error) {
+	valid, err := verifyTOTP(config.Db, userID, code)
+	if err != nil || valid {
+		return valid, err
+	}
+
+	result := config.Db.Model(&authModel.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, authUtils.HashToken(authUtils.NormalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// verifyTOTP checks a TOTP code of a confirmed enrollment and refuses codes already used
+func verifyTOTP(db *gorm.DB, userID uint, code string) (bool, error) {
+	var twoFactor authModel.TwoFactor
+	err := db.Where("user_id = ? AND confirmed_at IS NOT NULL", userID).First(&twoFactor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	step, valid := authUtils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+
+	// Concurrent requests with the same code can not both move the last used step forward
+	result := db.Model(&authModel.TwoFactor{}).
+		Where("id = ? AND last_used_step < ?", twoFactor.ID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// replaceRecoveryCodes deletes the recovery codes of the user and returns new ones, stored hashed
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := authUtils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&authModel.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	recoveryCodes := make([]authModel.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		recoveryCodes = append(recoveryCodes, authModel.RecoveryCode{
+			UserID:   userID,
+			CodeHash: authUtils.HashToken(authUtils.NormalizeRecoveryCode(code)),
+		})
+	}
+	if err := tx.Create(&recoveryCodes).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
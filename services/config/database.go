@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"go-blog/models/auth"
+	"go-blog/models/media"
 	"go-blog/models/post"
 	"go-blog/models/user"
 	"gorm.io/driver/mysql"
@@ -40,12 +41,34 @@ func Init() {
 		log.Fatalf("Database connection error: %s", err)
 	}
 
+	if err := migrateLegacyRefreshTokens(); err != nil {
+		log.Fatalf("Migration error: %v", err)
+	}
+	if err := prepareUniqueSlugs(&post.Post{}, &post.Category{}); err != nil {
+		log.Fatalf("Migration error: %v", err)
+	}
+
 	if err := Db.AutoMigrate(
 		&user.User{},
 		&auth.RefreshToken{},
+		&auth.Session{},
+		&auth.RevokedToken{},
+		&auth.TwoFactor{},
+		&auth.RecoveryCode{},
+		&auth.TwoFactorChallenge{},
+		&auth.LoginAttempt{},
+		&auth.Identity{},
+		&auth.OIDCLoginState{},
+		&auth.APIKey{},
+		&auth.PasswordResetToken{},
+		&media.Media{},
+		&media.MediaVariant{},
 		&post.Post{},
 		&post.Category{},
 		&post.Comment{},
+		&post.SlugRedirect{},
+		&post.PostRevision{},
+		&post.Tag{},
 	); err != nil {
 		log.Fatalf("Migration error: %v", err)
 	}
@@ -53,6 +76,64 @@ func Init() {
 	fmt.Println("Connected!")
 }
 
+// migrateLegacyRefreshTokens hashes the refresh tokens stored in clear by older versions, keeping existing sessions valid.
+// Each legacy token becomes its own token family.
+func migrateLegacyRefreshTokens() error {
+	migrator := Db.Migrator()
+	if !migrator.HasTable(&auth.RefreshToken{}) || !migrator.HasColumn(&auth.RefreshToken{}, "token") {
+		return nil
+	}
+
+	if !migrator.HasColumn(&auth.RefreshToken{}, "token_hash") {
+		if err := Db.Exec("ALTER TABLE refresh_tokens ADD COLUMN token_hash CHAR(64) NULL, ADD COLUMN family_id VARCHAR(64) NULL").Error; err != nil {
+			return err
+		}
+	}
+	if err := Db.Exec("UPDATE refresh_tokens SET token_hash = SHA2(token, 256), family_id = SHA2(token, 256)").Error; err != nil {
+		return err
+	}
+	return migrator.DropColumn(&auth.RefreshToken{}, "token")
+}
+
+// prepareUniqueSlugs replaces the non-unique slug indexes of older versions by unique ones. Empty and duplicate
+// slugs are cleared first, except for the oldest entity using them, and generated again by the slug backfill.
+func prepareUniqueSlugs(models ...interface{}) error {
+	migrator := Db.Migrator()
+	for _, model := range models {
+		if !migrator.HasTable(model) {
+			continue
+		}
+		indexes, err := migrator.GetIndexes(model)
+		if err != nil {
+			return err
+		}
+
+		for _, index := range indexes {
+			if unique, _ := index.Unique(); unique || len(index.Columns()) != 1 || index.Columns()[0] != "slug" {
+				continue
+			}
+
+			stmt := &gorm.Statement{DB: Db}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+			table := stmt.Schema.Table
+			if err := Db.Exec("UPDATE `" + table + "` SET slug = NULL WHERE slug = ''").Error; err != nil {
+				return err
+			}
+			if err := Db.Exec("UPDATE `" + table + "` t JOIN (SELECT slug, MIN(id) AS kept_id FROM `" + table +
+				"` WHERE slug IS NOT NULL GROUP BY slug HAVING COUNT(*) > 1) d ON t.slug = d.slug AND t.id <> d.kept_id " +
+				"SET t.slug = NULL").Error; err != nil {
+				return err
+			}
+			if err := migrator.DropIndex(model, index.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func buildConnectionString() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%s&loc=%s",
 		os.Getenv("DB_USER"),
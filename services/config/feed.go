@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// APIURL is the public base URL of the API, used for the self links of the feeds and the files served by the API
+	APIURL string
+	// FeedTitle and FeedDescription describe the blog in the syndication feeds
+	FeedTitle       string
+	FeedDescription string
+	// FeedItemCount is the number of posts listed in a feed, unless a limit is requested
+	FeedItemCount int
+	// FeedMaxItemCount is the maximum number of posts a feed can be requested with
+	FeedMaxItemCount int
+	// FeedCacheMaxAge is how long, in seconds, clients and proxies may cache a feed without revalidating it
+	FeedCacheMaxAge int
+)
+
+func InitFeedConfig() {
+	APIURL = strings.TrimSuffix(os.Getenv("API_URL"), "/")
+	if APIURL == "" {
+		APIURL = "http://localhost:8080"
+	}
+
+	FeedTitle = os.Getenv("FEED_TITLE")
+	if FeedTitle == "" {
+		FeedTitle = "Go Blog"
+	}
+	FeedDescription = os.Getenv("FEED_DESCRIPTION")
+	if FeedDescription == "" {
+		FeedDescription = "Latest posts of " + FeedTitle
+	}
+
+	FeedMaxItemCount = positiveIntEnv("FEED_MAX_ITEM_COUNT", 100)
+	FeedItemCount = positiveIntEnv("FEED_ITEM_COUNT", 20)
+	if FeedItemCount > FeedMaxItemCount {
+		FeedItemCount = FeedMaxItemCount
+	}
+	FeedCacheMaxAge = positiveIntEnv("FEED_CACHE_MAX_AGE", 300)
+}
+
+func positiveIntEnv(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -3,12 +3,22 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var (
 	JWTSecretKey    string
 	TokenExpiration time.Duration
+
+	// JWTIssuer and JWTAudience are set in the access tokens and required when validating them
+	JWTIssuer   string
+	JWTAudience string
+
+	// JWTPrivateKeyPath is the PEM file of the RSA or Ed25519 key signing the access tokens. HS256 with JWTSecretKey is used when empty.
+	JWTPrivateKeyPath string
+	// JWTVerificationKeyPaths are PEM files of older public keys still accepted while their tokens expire after a key rotation
+	JWTVerificationKeyPaths []string
 )
 
 func InitJWTConfig() {
@@ -19,4 +29,21 @@ func InitJWTConfig() {
 		expMinutes = 15 // fallback par défaut
 	}
 	TokenExpiration = time.Duration(expMinutes) * time.Minute
-}
\ No newline at end of file
+
+	JWTIssuer = os.Getenv("JWT_ISSUER")
+	if JWTIssuer == "" {
+		JWTIssuer = "go-blog"
+	}
+	JWTAudience = os.Getenv("JWT_AUDIENCE")
+	if JWTAudience == "" {
+		JWTAudience = "go-blog-api"
+	}
+
+	JWTPrivateKeyPath = os.Getenv("JWT_PRIVATE_KEY_PATH")
+	JWTVerificationKeyPaths = nil
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_PATHS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			JWTVerificationKeyPaths = append(JWTVerificationKeyPaths, path)
+		}
+	}
+}
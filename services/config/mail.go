@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	MailDriverSMTP = "smtp"
+	MailDriverLog  = "log"
+)
+
+var (
+	MailDriver   string
+	MailFrom     string
+	MailLogPath  string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// AppURL is the base URL of the frontend, used to build the links sent by email
+	AppURL string
+
+	PasswordResetExpiration time.Duration
+
+	// EmailVerificationEnabled makes new accounts PENDING until their email address is verified
+	EmailVerificationEnabled    bool
+	EmailVerificationExpiration time.Duration
+	// EmailVerificationSecret signs the email verification links, independently of the access token keys
+	EmailVerificationSecret string
+)
+
+func InitMailConfig() {
+	MailDriver = os.Getenv("MAIL_DRIVER")
+	if MailDriver == "" {
+		MailDriver = MailDriverLog
+	}
+	MailFrom = os.Getenv("MAIL_FROM")
+	if MailFrom == "" {
+		MailFrom = "no-reply@localhost"
+	}
+	MailLogPath = os.Getenv("MAIL_LOG_PATH")
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = os.Getenv("SMTP_PORT")
+	if SMTPPort == "" {
+		SMTPPort = "587"
+	}
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	AppURL = os.Getenv("APP_URL")
+	if AppURL == "" {
+		AppURL = "http://localhost:8080"
+	}
+
+	expMinutes, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_EXPIRATION_MINUTES"))
+	if err != nil {
+		expMinutes = 60
+	}
+	PasswordResetExpiration = time.Duration(expMinutes) * time.Minute
+
+	EmailVerificationEnabled, _ = strconv.ParseBool(os.Getenv("EMAIL_VERIFICATION_ENABLED"))
+	expHours, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_EXPIRATION_HOURS"))
+	if err != nil {
+		expHours = 24
+	}
+	EmailVerificationExpiration = time.Duration(expHours) * time.Hour
+	EmailVerificationSecret = os.Getenv("EMAIL_VERIFICATION_SECRET")
+}
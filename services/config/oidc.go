@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// OIDCProvider is an OpenID Connect identity provider users can log in with
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL must point to the /v1/oidc/{provider}/callback route, or to a frontend page forwarding the code and state to it
+	RedirectURL string
+	Scopes      []string
+}
+
+// OIDCProviders are the configured providers by name
+var OIDCProviders map[string]OIDCProvider
+
+// InitOIDCConfig reads the providers listed in OIDC_PROVIDERS, each configured with OIDC_<NAME>_* variables
+func InitOIDCConfig() {
+	OIDCProviders = map[string]OIDCProvider{}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		scopes := strings.Fields(strings.ReplaceAll(os.Getenv(prefix+"SCOPES"), ",", " "))
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+
+		OIDCProviders[name] = OIDCProvider{
+			Name:         name,
+			Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       scopes,
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows Requests requests per Period, with bursts of up to Requests requests
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+var (
+	RateLimitEnabled bool
+
+	// RateLimitGlobal applies per IP address to every API route
+	RateLimitGlobal RateLimit
+	// RateLimitAuth applies per IP address to the registration, login and password routes
+	RateLimitAuth RateLimit
+	// RateLimitUser applies per authenticated user to the routes requiring authentication
+	RateLimitUser RateLimit
+	// RateLimitComments applies per authenticated user to comment creation
+	RateLimitComments RateLimit
+)
+
+func InitRateLimitConfig() {
+	RateLimitEnabled = os.Getenv("RATE_LIMIT_ENABLED") != "false"
+
+	RateLimitGlobal = rateLimitFromEnv("RATE_LIMIT_GLOBAL", "300/1m")
+	RateLimitAuth = rateLimitFromEnv("RATE_LIMIT_AUTH", "10/1m")
+	RateLimitUser = rateLimitFromEnv("RATE_LIMIT_USER", "120/1m")
+	RateLimitComments = rateLimitFromEnv("RATE_LIMIT_COMMENTS", "5/1m")
+}
+
+func rateLimitFromEnv(key, fallback string) RateLimit {
+	value := os.Getenv(key)
+	if value == "" {
+		value = fallback
+	}
+	limit, err := ParseRateLimit(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return limit
+}
+
+// ParseRateLimit reads a rate limit written as requests/period, e.g. 10/1m or 1000/1h
+func ParseRateLimit(value string) (RateLimit, error) {
+	requests, period, found := strings.Cut(strings.TrimSpace(value), "/")
+	if !found {
+		return RateLimit{}, fmt.Errorf("expected requests/period, got %q", value)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid number of requests %q", requests)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid period %q", period)
+	}
+
+	return RateLimit{Requests: n, Period: d}, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	StorageDriverLocal = "local"
+	StorageDriverS3    = "s3"
+
+	// MediaPath is the path under which the local storage serves uploaded files
+	MediaPath = "/media"
+)
+
+var (
+	StorageDriver string
+
+	// StorageLocalPath is the directory where the local storage writes uploaded files
+	StorageLocalPath string
+
+	// S3Endpoint is the URL of an S3-compatible API, e.g. https://s3.eu-west-3.amazonaws.com or a MinIO server
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	// S3UsePathStyle addresses the bucket in the path rather than in the host name, as most local stand-ins require
+	S3UsePathStyle bool
+
+	// StoragePublicURL is the base URL of the uploaded files, e.g. a CDN in front of the bucket
+	StoragePublicURL string
+
+	// MediaMaxUploadSize is the maximum size of an uploaded file, in bytes
+	MediaMaxUploadSize int64
+	// MediaMaxImagePixels is the maximum number of pixels of an uploaded image, which is decoded in memory
+	MediaMaxImagePixels int
+)
+
+func InitStorageConfig() {
+	StorageDriver = os.Getenv("STORAGE_DRIVER")
+	if StorageDriver == "" {
+		StorageDriver = StorageDriverLocal
+	}
+
+	StorageLocalPath = os.Getenv("STORAGE_LOCAL_PATH")
+	if StorageLocalPath == "" {
+		StorageLocalPath = "uploads"
+	}
+
+	S3Endpoint = strings.TrimSuffix(os.Getenv("S3_ENDPOINT"), "/")
+	S3Region = os.Getenv("S3_REGION")
+	if S3Region == "" {
+		S3Region = "us-east-1"
+	}
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3AccessKeyID = os.Getenv("S3_ACCESS_KEY_ID")
+	S3SecretAccessKey = os.Getenv("S3_SECRET_ACCESS_KEY")
+	S3UsePathStyle, _ = strconv.ParseBool(os.Getenv("S3_USE_PATH_STYLE"))
+
+	StoragePublicURL = strings.TrimSuffix(os.Getenv("STORAGE_PUBLIC_URL"), "/")
+
+	maxMegabytes, err := strconv.Atoi(os.Getenv("MEDIA_MAX_UPLOAD_MB"))
+	if err != nil || maxMegabytes <= 0 {
+		maxMegabytes = 10
+	}
+	MediaMaxUploadSize = int64(maxMegabytes) << 20
+
+	maxMegapixels, err := strconv.Atoi(os.Getenv("MEDIA_MAX_IMAGE_MEGAPIXELS"))
+	if err != nil || maxMegapixels <= 0 {
+		maxMegapixels = 25
+	}
+	MediaMaxImagePixels = maxMegapixels * 1000 * 1000
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+var (
+	// TwoFactorIssuer is the name shown for the account in authenticator apps
+	TwoFactorIssuer string
+	// TwoFactorRequiredRoles are the roles that must enable two-factor authentication before using the API
+	TwoFactorRequiredRoles []string
+)
+
+func InitTwoFactorConfig() {
+	TwoFactorIssuer = os.Getenv("TWO_FACTOR_ISSUER")
+	if TwoFactorIssuer == "" {
+		TwoFactorIssuer = "Go Blog"
+	}
+
+	TwoFactorRequiredRoles = nil
+	for _, role := range strings.Split(os.Getenv("TWO_FACTOR_REQUIRED_ROLES"), ",") {
+		if role = strings.ToUpper(strings.TrimSpace(role)); role != "" {
+			TwoFactorRequiredRoles = append(TwoFactorRequiredRoles, role)
+		}
+	}
+}
+
+// IsTwoFactorRequired reports whether users with the given role must enable two-factor authentication
+func IsTwoFactorRequired(role string) bool {
+	for _, required := range TwoFactorRequiredRoles {
+		if strings.EqualFold(required, role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	"gorm.io/gorm"
+	"log"
+)
+
+// BackfillRenderedContent renders the HTML of posts and comments saved before their content was rendered.
+func BackfillRenderedContent() error {
+	var renderedPosts, renderedComments int
+
+	var posts []postModel.Post
+	result := config.Db.Unscoped().Where("content_html IS NULL OR content_html = ''").Where("content <> ''").
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				post.RenderContent()
+				if err := config.Db.Unscoped().Model(&post).
+					UpdateColumns(map[string]interface{}{"content_format": post.ContentFormat, "content_html": post.ContentHTML}).Error; err != nil {
+					return fmt.Errorf("error saving rendered content for post %d: %w", post.ID, err)
+				}
+			}
+			renderedPosts += len(posts)
+			return nil
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error rendering post content: %w", result.Error)
+	}
+
+	var comments []postModel.Comment
+	result = config.Db.Unscoped().Where("content_html IS NULL OR content_html = ''").Where("content <> ''").
+		FindInBatches(&comments, 100, func(tx *gorm.DB, batch int) error {
+			for _, comment := range comments {
+				comment.RenderContent()
+				if err := config.Db.Unscoped().Model(&comment).
+					UpdateColumns(map[string]interface{}{"content_format": comment.ContentFormat, "content_html": comment.ContentHTML}).Error; err != nil {
+					return fmt.Errorf("error saving rendered content for comment %d: %w", comment.ID, err)
+				}
+			}
+			renderedComments += len(comments)
+			return nil
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error rendering comment content: %w", result.Error)
+	}
+
+	if renderedPosts+renderedComments > 0 {
+		log.Printf("Content rendered for %d post(s) and %d comment(s)", renderedPosts, renderedComments)
+	}
+	return nil
+}
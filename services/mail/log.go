@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes emails to a file, or to the application log when no path is given.
+// It is meant for local development and tests.
+type LogMailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewLogMailer(path string) *LogMailer {
+	return &LogMailer{path: path}
+}
+
+func (m *LogMailer) Send(message Message) error {
+	entry := fmt.Sprintf("[%s] To: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), message.To, message.Subject, message.Body)
+
+	if m.path == "" {
+		log.Printf("[MAIL] %s", entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening mail log %s: %w", m.path, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing mail log (%s): %v", m.path, err)
+		}
+	}()
+
+	if _, err := file.WriteString(entry); err != nil {
+		return fmt.Errorf("error writing mail log %s: %w", m.path, err)
+	}
+	return nil
+}
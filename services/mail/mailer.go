@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"go-blog/services/config"
+	"log"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(message Message) error
+}
+
+var defaultMailer Mailer = NewLogMailer("")
+
+// Init selects the mailer from the MAIL_DRIVER configuration
+func Init() {
+	switch config.MailDriver {
+	case config.MailDriverSMTP:
+		defaultMailer = NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom)
+	case config.MailDriverLog:
+		defaultMailer = NewLogMailer(config.MailLogPath)
+	default:
+		log.Printf("Warning: unknown mail driver %q, emails will be logged", config.MailDriver)
+		defaultMailer = NewLogMailer(config.MailLogPath)
+	}
+}
+
+// Default returns the mailer used by the application
+func Default() Mailer {
+	return defaultMailer
+}
+
+// SetDefault replaces the mailer used by the application, e.g. with a LogMailer in tests
+func SetDefault(mailer Mailer) {
+	defaultMailer = mailer
+}
@@ -0,0 +1,358 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	mediaDTO "go-blog/dto/media"
+	mediaModel "go-blog/models/media"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	"go-blog/services/storage"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	mediaUtil "go-blog/utils/media"
+	requestUtil "go-blog/utils/post"
+	"gorm.io/gorm"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	Path                = "/media"
+	IdPath              = "/media/:id"
+	NotFound            = "Media not found"
+	Forbidden           = "You can only manage your own media"
+	FileRequired        = "A file is required in the file field"
+	FileTooLarge        = "The file exceeds the maximum upload size"
+	ImageTooLarge       = "The image has too many pixels"
+	MediaTypeNotAllowed = "Only JPEG, PNG, GIF and WebP images and PDF documents can be uploaded"
+)
+
+// UploadMedia @Summary Upload a file
+// @Description Upload an image or a document to the media library, as multipart/form-data. The type is detected from the content of the file. Thumbnail, medium and large variants are generated for JPEG, PNG and GIF images.
+// @Tags Media
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File to upload"
+// @Param alt_text formData string false "Alternative text of the image"
+// @Success 201 {object} mediaDTO.MediaResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 415 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/media [post]
+func UploadMedia(ctx *gin.Context) {
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	// Leave room for the other fields and the multipart boundaries
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, config.MediaMaxUploadSize+1<<20)
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, utils.NewErrorResponse(FileTooLarge))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse(FileRequired))
+		return
+	}
+	if fileHeader.Size > config.MediaMaxUploadSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, utils.NewErrorResponse(FileTooLarge))
+		return
+	}
+
+	altText := strings.TrimSpace(ctx.PostForm("alt_text"))
+	if len(altText) > 500 {
+		ctx.JSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "alt_text", "message": "Alt text must be at most 500 characters"},
+		}))
+		return
+	}
+
+	upload, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse(FileRequired))
+		return
+	}
+	defer upload.Close()
+	data, err := io.ReadAll(io.LimitReader(upload, config.MediaMaxUploadSize+1))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse(FileRequired))
+		return
+	}
+	if int64(len(data)) > config.MediaMaxUploadSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, utils.NewErrorResponse(FileTooLarge))
+		return
+	}
+
+	file, err := mediaUtil.Inspect(data, config.MediaMaxImagePixels)
+	if errors.Is(err, mediaUtil.ErrUnsupportedType) {
+		ctx.JSON(http.StatusUnsupportedMediaType, utils.NewErrorResponse(MediaTypeNotAllowed))
+		return
+	}
+	if errors.Is(err, mediaUtil.ErrImageTooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, utils.NewErrorResponse(ImageTooLarge))
+		return
+	}
+
+	media, err := storeMedia(ctx.Request.Context(), currentUser.ID, fileHeader.Filename, altText, file)
+	if err != nil {
+		log.Printf("Error storing upload of user %d: %v", currentUser.ID, err)
+		ctx.JSON(http.StatusInternalServerError, utils.NewErrorResponse("Error storing the file"))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, mediaDTO.ToMediaResponse(media))
+}
+
+// GetAllMedia @Summary Get paginated list of media
+// @Description List the media library, most recent first. Authors only see their own uploads, admins see every upload.
+// @Tags Media
+// @Produce json
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Items per page (default is 10)"
+// @Param type query string false "Only list images or documents" Enums(image, document)
+// @Success 200 {object} utils.PaginatedResponse[mediaDTO.MediaResponse]
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/media [get]
+func GetAllMedia(ctx *gin.Context) {
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+	page, limit, offset := requestUtil.ParsePaginationParams(ctx)
+
+	query := config.Db.Model(&mediaModel.Media{})
+	if !authUtils.IsAdmin(currentUser) {
+		query = query.Where("uploader_id = ?", currentUser.ID)
+	}
+	switch ctx.Query("type") {
+	case "image":
+		query = query.Where("mime_type LIKE ?", "image/%")
+	case "document":
+		query = query.Where("mime_type NOT LIKE ?", "image/%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error retrieving total count")
+		return
+	}
+
+	var media []mediaModel.Media
+	if err := query.Preload("Variants").Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&media).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error retrieving media from the database")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(mediaDTO.ToMediaResponses(media), page, limit, total))
+}
+
+// GetMediaByID @Summary Get media by ID
+// @Description Retrieve an uploaded file and its variants. Authors can only retrieve their own uploads.
+// @Tags Media
+// @Produce json
+// @Param id path int true "Media ID"
+// @Success 200 {object} mediaDTO.MediaResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/media/{id} [get]
+func GetMediaByID(ctx *gin.Context) {
+	media, ok := findManagedMedia(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, mediaDTO.ToMediaResponse(media))
+}
+
+// UpdateMedia @Summary Update media
+// @Description Update the alternative text of an uploaded file. Authors can only update their own uploads.
+// @Tags Media
+// @Accept json
+// @Produce json
+// @Param id path int true "Media ID"
+// @Param request body mediaDTO.MediaUpdateRequest true "Media update request"
+// @Success 200 {object} mediaDTO.MediaResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/media/{id} [put]
+func UpdateMedia(ctx *gin.Context) {
+	var request mediaDTO.MediaUpdateRequest
+	if !requestUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	media, ok := findManagedMedia(ctx)
+	if !ok {
+		return
+	}
+
+	media.AltText = strings.TrimSpace(request.AltText)
+	if err := config.Db.Model(&media).Update("alt_text", media.AltText).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error updating media")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mediaDTO.ToMediaResponse(media))
+}
+
+// DeleteMedia @Summary Delete media
+// @Description Delete an uploaded file and its variants from the storage. The file is detached from the posts using it, and removed as their cover image. Authors can only delete their own uploads.
+// @Tags Media
+// @Produce json
+// @Param id path int true "Media ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/media/{id} [delete]
+func DeleteMedia(ctx *gin.Context) {
+	media, ok := findManagedMedia(ctx)
+	if !ok {
+		return
+	}
+
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&postModel.Post{}).Where("cover_image_id = ?", media.ID).
+			UpdateColumns(map[string]interface{}{"cover_image_id": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM post_media WHERE media_id = ?", media.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("media_id = ?", media.ID).Delete(&mediaModel.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&media).Error
+	})
+	if err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error deleting media")
+		return
+	}
+
+	// The files are removed once nothing refers to them anymore
+	deleteFiles(ctx.Request.Context(), media.StorageKeys())
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Media deleted successfully"})
+}
+
+// findManagedMedia loads the media targeted by the request with its variants, when the current user may manage it
+func findManagedMedia(ctx *gin.Context) (mediaModel.Media, bool) {
+	var media mediaModel.Media
+	if err := config.Db.Preload("Variants").First(&media, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return media, false
+	}
+
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok || !mediaUtil.CanManageMedia(currentUser, media) {
+		ctx.JSON(http.StatusForbidden, utils.NewErrorResponse(Forbidden))
+		return media, false
+	}
+
+	return media, true
+}
+
+// storeMedia writes the file and its variants to the storage, then saves them in the media library.
+// Stored files are removed again when a later step fails.
+func storeMedia(ctx context.Context, uploaderID uint, fileName, altText string, file mediaUtil.File) (mediaModel.Media, error) {
+	variants, err := mediaUtil.GenerateVariants(file)
+	if err != nil {
+		return mediaModel.Media{}, err
+	}
+
+	key, err := mediaUtil.NewStorageKey(file.Extension)
+	if err != nil {
+		return mediaModel.Media{}, err
+	}
+
+	store := storage.Default()
+	var stored []string
+	put := func(key string, file mediaUtil.File) error {
+		if err := store.Put(ctx, key, file.Data, file.MimeType); err != nil {
+			deleteFiles(ctx, stored)
+			return err
+		}
+		stored = append(stored, key)
+		return nil
+	}
+
+	if err := put(key, file); err != nil {
+		return mediaModel.Media{}, err
+	}
+	media := mediaModel.Media{
+		UploaderID: &uploaderID,
+		FileName:   cleanFileName(fileName),
+		StorageKey: key,
+		URL:        store.URL(key),
+		MimeType:   file.MimeType,
+		Size:       int64(len(file.Data)),
+		Width:      file.Width,
+		Height:     file.Height,
+		AltText:    altText,
+	}
+
+	for _, variant := range variants {
+		variantKey := mediaUtil.VariantStorageKey(key, variant.Name, variant.Extension)
+		if err := put(variantKey, variant.File); err != nil {
+			return mediaModel.Media{}, err
+		}
+		media.Variants = append(media.Variants, mediaModel.MediaVariant{
+			Name:       variant.Name,
+			StorageKey: variantKey,
+			URL:        store.URL(variantKey),
+			MimeType:   variant.MimeType,
+			Size:       int64(len(variant.Data)),
+			Width:      variant.Width,
+			Height:     variant.Height,
+		})
+	}
+
+	if err := config.Db.Create(&media).Error; err != nil {
+		deleteFiles(ctx, stored)
+		return mediaModel.Media{}, err
+	}
+	return media, nil
+}
+
+// deleteFiles removes files from the storage, logging the failures as nothing refers to the files anymore
+func deleteFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := storage.Default().Delete(context.WithoutCancel(ctx), key); err != nil {
+			log.Printf("Error deleting stored file %s: %v", key, err)
+		}
+	}
+}
+
+// cleanFileName keeps the base name of the file sent by the client, which some browsers send with its path
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "upload"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return strings.ToValidUTF8(name, "")
+}
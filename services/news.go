@@ -11,12 +11,14 @@ import (
 	"go-blog/services/config"
 	postUtils "go-blog/utils/post"
 	"gorm.io/gorm"
+	"html"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 type NewsService struct {
@@ -130,14 +132,21 @@ func (ns *NewsService) savePost(newsPost dto.News, category postModel.Category)
 	}
 
 	// Create post
+	publishedAt := time.Now()
 	postData := postModel.Post{
-		Title:   newsPost.Title,
-		Excerpt: newsPost.Description,
-		Content: newsPost.Content + "<br><br><a href=\"" + newsPost.URL + "\">Read All...</a>",
+		Title:         newsPost.Title,
+		Excerpt:       newsPost.Description,
+		Content:       newsPost.Content + "<br><br><a href=\"" + html.EscapeString(newsPost.URL) + "\">Read All...</a>",
+		ContentFormat: postModel.ContentFormatHTML,
+		Status:        postModel.PostStatusPublished,
+		PublishedAt:   &publishedAt,
 	}
 
 	// Save post
-	if err := config.Db.Create(&postData).Error; err != nil {
+	if err := postUtils.SaveWithUniqueSlug(postModel.SlugEntityPost, newsPost.Title, "post", 0, func(slug string) error {
+		postData.Slug = slug
+		return config.Db.Create(&postData).Error
+	}); err != nil {
 		return fmt.Errorf("error DB insertion: %w", err)
 	}
 
@@ -1,19 +1,23 @@
 package post
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	categoryDTO "go-blog/dto/post"
 	postModel "go-blog/models/post"
 	"go-blog/services/config"
 	"go-blog/utils"
 	categoryUtil "go-blog/utils/post"
+	"gorm.io/gorm"
 	"net/http"
+	"strings"
 )
 
 const (
 	CategoryPath     = "/categories"
 	CategoryIDPath   = "/categories/:id"
 	CategoryTreePath = "/categories/tree"
+	CategorySlugPath = "/categories/slug/:slug"
 	CategoryNotFound = "Category not found"
 )
 
@@ -38,12 +42,16 @@ func CreateCategory(ctx *gin.Context) {
 		ParentID:    request.ParentID,
 	}
 
-	if err := config.Db.Create(&categoryData).Error; err != nil {
+	if err := categoryUtil.SaveWithUniqueSlug(postModel.SlugEntityCategory, request.Name, "category", 0, func(slug string) error {
+		categoryData.Slug = slug
+		return config.Db.Create(&categoryData).Error
+	}); err != nil {
 		categoryUtil.HandleDatabaseError(ctx, "Error saving category to the database")
 		return
 	}
 
 	response := categoryDTO.ToCategoryResponse(categoryData)
+	categoryUtil.SetETag(ctx, categoryData.Version)
 	ctx.JSON(http.StatusCreated, response)
 }
 
@@ -108,11 +116,13 @@ func GetCategoryTree(ctx *gin.Context) {
 }
 
 // GetCategoryByID @Summary Get category by ID
-// @Description Retrieve a specific category by its ID
+// @Description Retrieve a specific category by its ID. The ETag header holds the version of the category.
 // @Tags Categories
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param If-None-Match header string false "ETag of the cached version"
 // @Success 200 {object} post.CategoryResponseDoc
+// @Success 304 {string} string "Not modified"
 // @Failure 404 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /v1/categories/{id} [get]
@@ -123,20 +133,59 @@ func GetCategoryByID(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CategoryNotFound))
 		return
 	}
+	if categoryUtil.NotModified(ctx, categoryModel.Version) {
+		return
+	}
+	response := categoryDTO.ToCategoryResponse(categoryModel)
+	categoryUtil.SetETag(ctx, categoryModel.Version)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetCategoryBySlug @Summary Get category by slug
+// @Description Retrieve a specific category by its slug. Old slugs are redirected to the current one. The ETag header holds the version of the category.
+// @Tags Categories
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param If-None-Match header string false "ETag of the cached version"
+// @Success 200 {object} post.CategoryResponseDoc
+// @Success 304 {string} string "Not modified"
+// @Success 301 {string} string "Moved permanently to the current slug"
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /v1/categories/slug/{slug} [get]
+func GetCategoryBySlug(ctx *gin.Context) {
+	var categoryModel postModel.Category
+	slug := ctx.Param("slug")
+	if err := config.Db.Preload("Children").Where("slug = ?", slug).First(&categoryModel).Error; err != nil {
+		categoryID, err := categoryUtil.FindSlugRedirect(postModel.SlugEntityCategory, slug)
+		if err != nil || config.Db.First(&categoryModel, categoryID).Error != nil {
+			ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CategoryNotFound))
+			return
+		}
+		ctx.Redirect(http.StatusMovedPermanently, strings.TrimSuffix(ctx.Request.URL.Path, slug)+categoryModel.Slug)
+		return
+	}
+	if categoryUtil.NotModified(ctx, categoryModel.Version) {
+		return
+	}
 	response := categoryDTO.ToCategoryResponse(categoryModel)
+	categoryUtil.SetETag(ctx, categoryModel.Version)
 	ctx.JSON(http.StatusOK, response)
 }
 
 // UpdateCategory @Summary Update a category
-// @Description Update an existing category by its ID
+// @Description Update an existing category by its ID. The version the update is based on must be sent in an If-Match header or the version field.
 // @Tags Categories
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body post.CategoryRequest true "Category update request"
 // @Success 200 {object} post.CategoryResponseDoc
 // @Failure 400 {object} utils.ValidationErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ConflictResponse "The version field is not the current version"
+// @Failure 412 {object} utils.ConflictResponse "The If-Match header is not the current version"
+// @Failure 428 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /v1/categories/{id} [put]
 func UpdateCategory(ctx *gin.Context) {
@@ -144,12 +193,18 @@ func UpdateCategory(ctx *gin.Context) {
 	if !categoryUtil.BindAndValidateJSON(ctx, &request) {
 		return
 	}
+	precondition, ok := categoryUtil.RequirePrecondition(ctx, request.Version)
+	if !ok {
+		return
+	}
 	var categoryModel postModel.Category
 	id := ctx.Param("id")
 	if err := config.Db.First(&categoryModel, id).Error; err != nil {
 		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CategoryNotFound))
 		return
 	}
+	oldSlug := categoryModel.Slug
+	renamed := request.Name != categoryModel.Name || categoryModel.Slug == ""
 	categoryModel.Name = request.Name
 	categoryModel.Description = request.Description
 	if request.ParentID != nil {
@@ -157,8 +212,31 @@ func UpdateCategory(ctx *gin.Context) {
 	} else {
 		categoryModel.ParentID = nil
 	}
-	if err := config.Db.Save(&categoryModel).Error; err != nil {
-		categoryUtil.HandleDatabaseError(ctx, "Error updating category")
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := claimCategoryVersion(tx, &categoryModel, precondition); err != nil {
+			return err
+		}
+		// The version was incremented by the claim, so it is not written again
+		save := func(slug string) error {
+			categoryModel.Slug = slug
+			return tx.Omit("version").Save(&categoryModel).Error
+		}
+		var err error
+		if renamed {
+			err = categoryUtil.SaveWithUniqueSlug(postModel.SlugEntityCategory, request.Name, "category", categoryModel.ID, save)
+		} else {
+			err = save(categoryModel.Slug)
+		}
+		if err != nil {
+			return err
+		}
+		if oldSlug != categoryModel.Slug {
+			return categoryUtil.RecordSlugRedirect(tx, postModel.SlugEntityCategory, oldSlug, categoryModel.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		respondCategoryError(ctx, categoryModel.ID, precondition, err, "Error updating category")
 		return
 	}
 	if err := config.Db.Preload("Children").First(&categoryModel, id).Error; err != nil {
@@ -166,22 +244,32 @@ func UpdateCategory(ctx *gin.Context) {
 		return
 	}
 	response := categoryDTO.ToCategoryResponse(categoryModel)
+	categoryUtil.SetETag(ctx, categoryModel.Version)
 	ctx.JSON(http.StatusOK, response)
 }
 
 // DeleteCategory @Summary Delete a category
-// @Description Delete a category and handle its children (recursively or by reassignment)
+// @Description Delete a category and handle its children (recursively or by reassignment). The version the deletion is based on must be sent in an If-Match header or the version query parameter.
 // @Tags Categories
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag of the version the deletion is based on"
+// @Param version query int false "Version the deletion is based on, when no If-Match header is sent"
 // @Param recursive query bool false "Delete children recursively"
 // @Param reassign_to query int false "ID of category to reassign children to"
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ConflictResponse "The version parameter is not the current version"
+// @Failure 412 {object} utils.ConflictResponse "The If-Match header is not the current version"
+// @Failure 428 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /v1/categories/{id} [delete]
 func DeleteCategory(ctx *gin.Context) {
+	precondition, ok := categoryUtil.RequirePrecondition(ctx, nil)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 	var category postModel.Category
 	if err := config.Db.Preload("Children").First(&category, id).Error; err != nil {
@@ -190,33 +278,62 @@ func DeleteCategory(ctx *gin.Context) {
 	}
 	recursive := ctx.Query("recursive") == "true"
 	reassignTo := ctx.Query("reassign_to")
-	if len(category.Children) > 0 {
-		switch {
-		case recursive:
-			if err := categoryUtil.DeleteCategoryRecursively(&category); err != nil {
-				categoryUtil.HandleDatabaseError(ctx, "Failed to delete children categories")
-				return
-			}
-		case reassignTo != "":
-			var newParent postModel.Category
-			if err := config.Db.First(&newParent, reassignTo).Error; err != nil {
-				ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Reassign target category not found"))
-				return
-			}
-			if err := config.Db.Model(&postModel.Category{}).
-				Where("parent_id = ?", category.ID).
-				Update("parent_id", newParent.ID).Error; err != nil {
-				categoryUtil.HandleDatabaseError(ctx, "Failed to reassign child categories")
-				return
-			}
-		default:
+	var newParent postModel.Category
+	if len(category.Children) > 0 && !recursive {
+		if reassignTo == "" {
 			ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Cannot delete category with children. Use recursive=true or reassign_to={id}"))
 			return
 		}
+		if err := config.Db.First(&newParent, reassignTo).Error; err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Reassign target category not found"))
+			return
+		}
 	}
-	if err := config.Db.Delete(&category).Error; err != nil {
-		categoryUtil.HandleDatabaseError(ctx, "Failed to delete category")
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := claimCategoryVersion(tx, &category, precondition); err != nil {
+			return err
+		}
+		if len(category.Children) > 0 {
+			if recursive {
+				return categoryUtil.DeleteCategoryRecursively(tx, &category)
+			}
+			if err := tx.Model(&postModel.Category{}).
+				Where("parent_id = ?", category.ID).
+				Updates(map[string]interface{}{"parent_id": newParent.ID, "version": gorm.Expr("version + 1")}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		respondCategoryError(ctx, category.ID, precondition, err, "Failed to delete category")
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
+
+// claimCategoryVersion increments the version of the category within the transaction changing it. It returns
+// categoryUtil.ErrVersionConflict when the category is no longer at the expected version.
+func claimCategoryVersion(tx *gorm.DB, category *postModel.Category, precondition categoryUtil.Precondition) error {
+	if err := categoryUtil.ClaimVersion(tx, &postModel.Category{}, category.ID, category.Version, precondition); err != nil {
+		return err
+	}
+	category.Version++
+	return nil
+}
+
+// respondCategoryError responds to a failed change of the category: with the current category when it is no longer
+// at the expected version, or with a database error
+func respondCategoryError(ctx *gin.Context, categoryID uint, precondition categoryUtil.Precondition, err error, message string) {
+	if !errors.Is(err, categoryUtil.ErrVersionConflict) {
+		categoryUtil.HandleDatabaseError(ctx, message)
+		return
+	}
+
+	var current postModel.Category
+	if err := config.Db.Preload("Children").First(&current, categoryID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CategoryNotFound))
+		return
+	}
+	categoryUtil.RespondVersionConflict(ctx, precondition, current.Version, categoryDTO.ToCategoryResponse(current))
+}
@@ -5,11 +5,14 @@ import (
 	"github.com/gin-gonic/gin"
 	commentDTO "go-blog/dto/post"
 	commentModel "go-blog/models/post"
+	"go-blog/models/user"
 	"go-blog/services/config"
 	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
 	commentUtil "go-blog/utils/post"
 	"gorm.io/gorm"
 	"net/http"
+	"strconv"
 )
 
 const (
@@ -18,14 +21,17 @@ const (
 	CommentByPostIDPath = "/comments/post/:id"
 	CommentIdPath       = "/comments/:id"
 	CommentNotFound     = "Comment not found"
+	CommentForbidden    = "You are not allowed to modify this comment"
 )
 
 // GetAllComments @Summary Get all comments
-// @Description Get list of all comments
+// @Description Get list of all comments, whatever their moderation status. Only available to admins.
 // @Tags Comments
 // @Produce json
 // @Success 200 {array} commentDTO.CommentResponseDoc
+// @Failure 403 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
 // @Router /v1/comments/all [get]
 func GetAllComments(ctx *gin.Context) {
 	var comments []commentModel.Comment
@@ -43,7 +49,7 @@ func GetAllComments(ctx *gin.Context) {
 }
 
 // GetCommentByPostID @Summary Get comments by post ID
-// @Description Get paginated comments for a specific post
+// @Description Get paginated approved comments for a specific post, including the authenticated viewer's own pending comments
 // @Tags Comments
 // @Produce json
 // @Param id path string true "Post ID"
@@ -61,8 +67,23 @@ func GetCommentByPostID(ctx *gin.Context) {
 		return
 	}
 
-	// Fetch all comments for the post
-	allComments, err := commentUtil.FetchCommentsForPost(postID)
+	// Comments of unpublished posts are only visible to those who may see the post
+	id, err := strconv.ParseUint(postID, 10, 0)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid post ID"))
+		return
+	}
+	viewer := authUtils.GetViewer(ctx)
+	if !findVisiblePost(ctx, uint(id), viewer) {
+		return
+	}
+
+	// Fetch the comments visible to the viewer
+	var viewerID *uint
+	if viewer != nil {
+		viewerID = &viewer.ID
+	}
+	allComments, err := commentUtil.FetchCommentsForPost(postID, viewerID)
 	if err != nil {
 		commentUtil.HandleDatabaseError(ctx, "Error retrieving comments")
 		return
@@ -83,30 +104,73 @@ func GetCommentByPostID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(pagedComments, page, limit, int64(total)))
 }
 
+// GetCommentByID @Summary Get comment by ID
+// @Description Get a comment by its ID. Pending and rejected comments are only visible to their author and admins. The ETag header holds the version of the comment.
+// @Tags Comments
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param If-None-Match header string false "ETag of the cached version"
+// @Success 200 {object} commentDTO.CommentResponse
+// @Success 304 {string} string "Not modified"
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /v1/comments/{id} [get]
+func GetCommentByID(ctx *gin.Context) {
+	var comment commentModel.Comment
+	if err := config.Db.Preload("User").First(&comment, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CommentNotFound))
+		return
+	}
+	viewer := authUtils.GetViewer(ctx)
+	if comment.Status != commentModel.CommentStatusApproved {
+		if viewer == nil || !commentUtil.CanManageComment(*viewer, comment) {
+			ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CommentNotFound))
+			return
+		}
+	}
+	var post commentModel.Post
+	if err := config.Db.First(&post, comment.PostID).Error; err != nil || !commentUtil.CanViewPost(viewer, post) {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CommentNotFound))
+		return
+	}
+	if commentUtil.NotModified(ctx, comment.Version) {
+		return
+	}
+	commentUtil.SetETag(ctx, comment.Version)
+	ctx.JSON(http.StatusOK, commentDTO.ToCommentResponse(comment))
+}
+
 // AddComment @Summary Add new comment
-// @Description Create a new comment
+// @Description Create a new comment as the authenticated user, written in Markdown or HTML. Only basic formatting is kept, and links are marked nofollow. New comments are always pending moderation.
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param comment body commentDTO.CommentRequest true "Comment data"
 // @Success 201 {object} commentDTO.CommentResponse
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse "Post not found"
 // @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
 // @Router /v1/comments [post]
 func AddComment(ctx *gin.Context) {
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
 	var request commentDTO.CommentRequest
 	if !commentUtil.BindAndValidateJSON(ctx, &request) {
 		return
 	}
-	status := commentModel.CommentStatusPending
-	if request.Status != nil {
-		status = *request.Status
+	if !findVisiblePost(ctx, request.PostID, &currentUser) {
+		return
 	}
 	commentData := commentModel.Comment{
-		PostID:  request.PostID,
-		UserID:  request.UserID,
-		Content: request.Content,
-		Status:  status,
+		PostID:        request.PostID,
+		UserID:        currentUser.ID,
+		Content:       request.Content,
+		ContentFormat: request.ContentFormat,
+		Status:        commentModel.CommentStatusPending,
 	}
 	if request.ParentID != nil {
 		commentData.ParentID = request.ParentID
@@ -122,63 +186,113 @@ func AddComment(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, utils.NewErrorResponse("Error loading comment"))
 		return
 	}
+	commentUtil.SetETag(ctx, commentData.Version)
 	ctx.JSON(http.StatusCreated, commentDTO.ToCommentResponse(commentData))
 }
 
 // UpdateComment @Summary Update comment
-// @Description Update an existing comment
+// @Description Update an existing comment. Only the comment owner or an admin can edit it; an edit by the owner sends the comment back to moderation. The version the update is based on must be sent in an If-Match header or the version field.
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param id path string true "Comment ID"
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param comment body commentDTO.CommentUpdateRequest true "Updated comment data"
 // @Success 200 {object} commentDTO.CommentResponse
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ConflictResponse "The version field is not the current version"
+// @Failure 412 {object} utils.ConflictResponse "The If-Match header is not the current version"
+// @Failure 428 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
 // @Router /v1/comments/{id} [put]
 func UpdateComment(ctx *gin.Context) {
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
 	var request commentDTO.CommentUpdateRequest
 	if !commentUtil.BindAndValidateJSON(ctx, &request) {
 		return
 	}
+	precondition, ok := commentUtil.RequirePrecondition(ctx, request.Version)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 	var existingComment commentModel.Comment
 	if err := config.Db.First(&existingComment, id).Error; err != nil {
 		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CommentNotFound))
 		return
 	}
+	if !commentUtil.CanManageComment(currentUser, existingComment) {
+		ctx.JSON(http.StatusForbidden, utils.NewErrorResponse(CommentForbidden))
+		return
+	}
 	existingComment.Content = request.Content
-	if request.Status != "" {
-		existingComment.Status = request.Status
+	if request.ContentFormat != "" {
+		existingComment.ContentFormat = request.ContentFormat
+	}
+	if !authUtils.IsAdmin(currentUser) {
+		// An edited comment has to go through moderation again
+		existingComment.Status = commentModel.CommentStatusPending
 	}
-	if err := config.Db.Save(&existingComment).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.NewErrorResponse("Failed to update comment"))
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := claimCommentVersion(tx, &existingComment, precondition); err != nil {
+			return err
+		}
+		// The version was incremented by the claim, so it is not written again
+		return tx.Omit("version").Save(&existingComment).Error
+	})
+	if err != nil {
+		respondCommentError(ctx, existingComment.ID, precondition, err, "Failed to update comment")
 		return
 	}
 	if err := config.Db.Preload("User").First(&existingComment, existingComment.ID).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, utils.NewErrorResponse("Error loading comment author"))
 		return
 	}
+	commentUtil.SetETag(ctx, existingComment.Version)
 	ctx.JSON(http.StatusOK, commentDTO.ToCommentResponse(existingComment))
 }
 
 // DeleteComment @Summary Delete comment
-// @Description Delete a comment
+// @Description Delete a comment. Only the comment owner or an admin can delete it. The version the deletion is based on must be sent in an If-Match header or the version query parameter.
 // @Tags Comments
 // @Produce json
 // @Param id path string true "Comment ID"
+// @Param If-Match header string false "ETag of the version the deletion is based on"
+// @Param version query int false "Version the deletion is based on, when no If-Match header is sent"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ConflictResponse "The version parameter is not the current version"
+// @Failure 412 {object} utils.ConflictResponse "The If-Match header is not the current version"
+// @Failure 428 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
 // @Router /v1/comments/{id} [delete]
 func DeleteComment(ctx *gin.Context) {
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
 	id := ctx.Param("id")
 	if id == "" {
 		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Missing comment ID"))
 		return
 	}
+	precondition, ok := commentUtil.RequirePrecondition(ctx, nil)
+	if !ok {
+		return
+	}
 	var comment commentModel.Comment
 	if err := config.Db.First(&comment, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -188,9 +302,55 @@ func DeleteComment(ctx *gin.Context) {
 		}
 		return
 	}
-	if err := config.Db.Delete(&comment).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.NewErrorResponse("Error deleting comment"))
+	if !commentUtil.CanManageComment(currentUser, comment) {
+		ctx.JSON(http.StatusForbidden, utils.NewErrorResponse(CommentForbidden))
+		return
+	}
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := claimCommentVersion(tx, &comment, precondition); err != nil {
+			return err
+		}
+		return tx.Delete(&comment).Error
+	})
+	if err != nil {
+		respondCommentError(ctx, comment.ID, precondition, err, "Error deleting comment")
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
+
+// claimCommentVersion increments the version of the comment within the transaction changing it. It returns
+// commentUtil.ErrVersionConflict when the comment is no longer at the expected version.
+func claimCommentVersion(tx *gorm.DB, comment *commentModel.Comment, precondition commentUtil.Precondition) error {
+	if err := commentUtil.ClaimVersion(tx, &commentModel.Comment{}, comment.ID, comment.Version, precondition); err != nil {
+		return err
+	}
+	comment.Version++
+	return nil
+}
+
+// respondCommentError responds to a failed change of the comment: with the current comment when it is no longer at
+// the expected version, or with a database error
+func respondCommentError(ctx *gin.Context, commentID uint, precondition commentUtil.Precondition, err error, message string) {
+	if !errors.Is(err, commentUtil.ErrVersionConflict) {
+		ctx.JSON(http.StatusInternalServerError, utils.NewErrorResponse(message))
+		return
+	}
+
+	var current commentModel.Comment
+	if err := config.Db.Preload("User").First(&current, commentID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CommentNotFound))
+		return
+	}
+	commentUtil.RespondVersionConflict(ctx, precondition, current.Version, commentDTO.ToCommentResponse(current))
+}
+
+// findVisiblePost responds with a 404 and returns false when the post does not exist or the viewer may not see it
+func findVisiblePost(ctx *gin.Context, postID uint, viewer *user.User) bool {
+	var post commentModel.Post
+	if err := config.Db.First(&post, postID).Error; err != nil || !commentUtil.CanViewPost(viewer, post) {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package post
+
+import (
+	"github.com/gin-gonic/gin"
+	commentDTO "go-blog/dto/post"
+	commentModel "go-blog/models/post"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	commentUtil "go-blog/utils/post"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+const (
+	CommentModerationPath        = "/comments/moderation"
+	CommentModerationApprovePath = "/comments/moderation/approve"
+	CommentModerationRejectPath  = "/comments/moderation/reject"
+)
+
+// GetModerationQueue @Summary Get comment moderation queue
+// @Description Get a paginated list of comments awaiting moderation, optionally filtered by post
+// @Tags Comments
+// @Produce json
+// @Param post_id query int false "Post ID to filter by"
+// @Param status query string false "Comment status (default is PENDING)" Enums(PENDING, APPROVED, REJECTED)
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Items per page (default is 10)"
+// @Success 200 {object} utils.PaginatedResponse[commentDTO.CommentResponse]
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/comments/moderation [get]
+func GetModerationQueue(ctx *gin.Context) {
+	page, limit, offset := commentUtil.ParsePaginationParams(ctx)
+
+	status := ctx.DefaultQuery("status", commentModel.CommentStatusPending)
+	if !commentUtil.IsValidCommentStatus(status) {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid status"))
+		return
+	}
+
+	query := config.Db.Model(&commentModel.Comment{}).Where("status = ?", status)
+	if postID := ctx.Query("post_id"); postID != "" {
+		query = query.Where("post_id = ?", postID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		commentUtil.HandleDatabaseError(ctx, "Error retrieving total count")
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	if totalPages > 0 && page > totalPages {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse("Page not found"))
+		return
+	}
+
+	var comments []commentModel.Comment
+	if err := query.
+		Preload("User").
+		Limit(limit).
+		Offset(offset).
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		commentUtil.HandleDatabaseError(ctx, "Error retrieving comments")
+		return
+	}
+
+	response := make([]*commentDTO.CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		response = append(response, commentDTO.ToCommentResponse(comment))
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+}
+
+// ApproveComments @Summary Approve comments
+// @Description Approve one or more comments in bulk
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param request body commentDTO.CommentModerationRequest true "Comments to approve"
+// @Success 200 {object} commentDTO.CommentModerationResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/comments/moderation/approve [post]
+func ApproveComments(ctx *gin.Context) {
+	moderateComments(ctx, commentModel.CommentStatusApproved)
+}
+
+// RejectComments @Summary Reject comments
+// @Description Reject one or more comments in bulk with an optional reason
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param request body commentDTO.CommentModerationRequest true "Comments to reject"
+// @Success 200 {object} commentDTO.CommentModerationResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/comments/moderation/reject [post]
+func RejectComments(ctx *gin.Context) {
+	moderateComments(ctx, commentModel.CommentStatusRejected)
+}
+
+func moderateComments(ctx *gin.Context, status string) {
+	moderator, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	var request commentDTO.CommentModerationRequest
+	if !commentUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	result := config.Db.Model(&commentModel.Comment{}).
+		Where("id IN ?", request.CommentIDs).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"moderated_by_id":   moderator.ID,
+			"moderated_at":      time.Now(),
+			"moderation_reason": request.Reason,
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		commentUtil.HandleDatabaseError(ctx, "Error updating comment status")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, commentDTO.CommentModerationResponse{
+		Status:     status,
+		Updated:    result.RowsAffected,
+		CommentIDs: request.CommentIDs,
+	})
+}
@@ -0,0 +1,280 @@
+package post
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	postModel "go-blog/models/post"
+	userModel "go-blog/models/user"
+	"go-blog/services/config"
+	"go-blog/utils"
+	"go-blog/utils/feed"
+	postUtil "go-blog/utils/post"
+	"go-blog/utils/search"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FeedPath         = "/feeds/:file"
+	CategoryFeedPath = "/feeds/categories/:slug/:file"
+	AuthorFeedPath   = "/feeds/authors/:id/:file"
+	FeedNotFound     = "Feed not found, expected rss.xml, atom.xml or feed.json"
+	AuthorNotFound   = "Author not found"
+)
+
+// GetFeed @Summary Get the feed of the blog
+// @Description Syndicate the latest published posts as RSS 2.0, Atom or JSON Feed, newest first. Feeds can be cached and revalidated with their ETag and Last-Modified headers.
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param file path string true "Feed format" Enums(rss.xml, atom.xml, feed.json)
+// @Param limit query int false "Number of posts (default and maximum are configured)"
+// @Param If-None-Match header string false "ETag of the cached feed"
+// @Param If-Modified-Since header string false "Last-Modified date of the cached feed"
+// @Success 200 {string} string "Feed document"
+// @Success 304 {string} string "Not modified"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /feeds/{file} [get]
+func GetFeed(ctx *gin.Context) {
+	serveFeed(ctx, search.PostQuery{}, config.FeedTitle, config.FeedDescription)
+}
+
+// GetCategoryFeed @Summary Get the feed of a category
+// @Description Syndicate the latest published posts of a category as RSS 2.0, Atom or JSON Feed, newest first.
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param file path string true "Feed format" Enums(rss.xml, atom.xml, feed.json)
+// @Param limit query int false "Number of posts (default and maximum are configured)"
+// @Param If-None-Match header string false "ETag of the cached feed"
+// @Param If-Modified-Since header string false "Last-Modified date of the cached feed"
+// @Success 200 {string} string "Feed document"
+// @Success 301 {string} string "Moved permanently to the current slug"
+// @Success 304 {string} string "Not modified"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /feeds/categories/{slug}/{file} [get]
+func GetCategoryFeed(ctx *gin.Context) {
+	var category postModel.Category
+	slug := ctx.Param("slug")
+	if err := config.Db.Where("slug = ?", slug).First(&category).Error; err != nil {
+		categoryID, err := postUtil.FindSlugRedirect(postModel.SlugEntityCategory, slug)
+		if err != nil || config.Db.First(&category, categoryID).Error != nil {
+			ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(CategoryNotFound))
+			return
+		}
+		location := *ctx.Request.URL
+		location.Path = strings.Replace(location.Path, "/"+slug+"/", "/"+category.Slug+"/", 1)
+		ctx.Redirect(http.StatusMovedPermanently, location.RequestURI())
+		return
+	}
+
+	description := category.Description
+	if description == "" {
+		description = config.FeedDescription
+	}
+	serveFeed(ctx, search.PostQuery{CategoryIDs: []uint{category.ID}}, config.FeedTitle+" - "+category.Name, description)
+}
+
+// GetAuthorFeed @Summary Get the feed of an author
+// @Description Syndicate the latest published posts of an author as RSS 2.0, Atom or JSON Feed, newest first.
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param id path int true "Author ID"
+// @Param file path string true "Feed format" Enums(rss.xml, atom.xml, feed.json)
+// @Param limit query int false "Number of posts (default and maximum are configured)"
+// @Param If-None-Match header string false "ETag of the cached feed"
+// @Param If-Modified-Since header string false "Last-Modified date of the cached feed"
+// @Success 200 {string} string "Feed document"
+// @Success 304 {string} string "Not modified"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /feeds/authors/{id}/{file} [get]
+func GetAuthorFeed(ctx *gin.Context) {
+	var author userModel.User
+	if err := config.Db.First(&author, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(AuthorNotFound))
+		return
+	}
+
+	name := author.FirstName + " " + author.LastName
+	description := author.Bio
+	if description == "" {
+		description = "Latest posts of " + name
+	}
+	serveFeed(ctx, search.PostQuery{AuthorID: &author.ID}, config.FeedTitle+" - "+name, description)
+}
+
+// serveFeed responds with the latest published posts matching the filters, in the format requested by the file
+// name. The feed is only loaded and rendered when the copy cached by the client is outdated.
+func serveFeed(ctx *gin.Context, filters search.PostQuery, title, description string) {
+	format, ok := feed.Formats[ctx.Param("file")]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(FeedNotFound))
+		return
+	}
+
+	limit := config.FeedItemCount
+	if limitParam := ctx.Query("limit"); limitParam != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid limit"))
+			return
+		}
+		limit = min(limit, config.FeedMaxItemCount)
+	}
+
+	// Feeds only list published posts, whoever requests them
+	filters.Viewer = nil
+	var entries []postModel.Post
+	if err := filterPosts(config.Db.Model(&postModel.Post{}), filters).
+		Select("posts.id", "posts.version", "posts.updated_at").
+		Order("COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error retrieving posts from the database")
+		return
+	}
+
+	// The entity tag changes with the feed, its length and any change to one of its posts
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n%s\n%d\n", ctx.Param("file"), title, description, config.AppURL, config.APIURL, limit)
+	var lastModified time.Time
+	ids := make([]uint, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+		fmt.Fprintf(hash, "%d:%d\n", entry.ID, entry.Version)
+		if entry.UpdatedAt.After(lastModified) {
+			lastModified = entry.UpdatedAt
+		}
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", config.FeedCacheMaxAge))
+	ctx.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if feedNotModified(ctx, etag, lastModified) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	var posts []postModel.Post
+	if len(ids) > 0 {
+		if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage").
+			Where("id IN ?", ids).Find(&posts).Error; err != nil {
+			postUtil.HandleDatabaseError(ctx, "Error retrieving posts from the database")
+			return
+		}
+	}
+
+	body, err := format.Render(buildFeed(ctx, title, description, lastModified, ids, posts))
+	if err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error rendering feed")
+		return
+	}
+	ctx.Data(http.StatusOK, format.ContentType, body)
+}
+
+// feedNotModified reports whether the client already has the current feed. As in RFC 9110, If-Modified-Since is
+// ignored when If-None-Match is sent.
+func feedNotModified(ctx *gin.Context, etag string, lastModified time.Time) bool {
+	if header := ctx.GetHeader("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(ctx.GetHeader("If-Modified-Since"))
+	return err == nil && !lastModified.IsZero() && !lastModified.Truncate(time.Second).After(since)
+}
+
+// buildFeed lists the posts in the order of their IDs
+func buildFeed(ctx *gin.Context, title, description string, updated time.Time, ids []uint, posts []postModel.Post) feed.Feed {
+	// The URLs come from the configuration rather than the Host header, since the feeds are cached by proxies
+	siteURL := strings.TrimSuffix(config.AppURL, "/")
+	baseURL := config.APIURL
+
+	byID := make(map[uint]postModel.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	items := make([]feed.Item, 0, len(ids))
+	for _, id := range ids {
+		post, ok := byID[id]
+		if !ok {
+			continue
+		}
+
+		published := post.CreatedAt
+		if post.PublishedAt != nil {
+			published = *post.PublishedAt
+		}
+
+		item := feed.Item{
+			ID:          feed.TagURI(siteURL, post.CreatedAt, "post", post.ID),
+			Title:       post.Title,
+			Link:        siteURL + "/posts/" + url.PathEscape(post.Slug),
+			Summary:     post.Excerpt,
+			ContentHTML: post.ContentHTML,
+			Published:   published,
+			Updated:     post.UpdatedAt,
+		}
+		if post.Author != nil {
+			item.Author = post.Author.FirstName + " " + post.Author.LastName
+		}
+		for _, category := range post.Categories {
+			item.Categories = append(item.Categories, category.Name)
+		}
+		for _, tag := range post.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+		if post.CoverImage != nil {
+			item.Image = &feed.Image{
+				URL:      absoluteURL(baseURL, post.CoverImage.URL),
+				MimeType: post.CoverImage.MimeType,
+				Size:     post.CoverImage.Size,
+			}
+		}
+		items = append(items, item)
+	}
+
+	return feed.Feed{
+		Title:       title,
+		Description: description,
+		Link:        siteURL,
+		SelfURL:     baseURL + ctx.Request.URL.Path,
+		Updated:     updated,
+		Items:       items,
+	}
+}
+
+// absoluteURL resolves the URL of a file served by the API itself, such as the media of the local storage
+func absoluteURL(baseURL, reference string) string {
+	base, err := url.Parse(baseURL + "/")
+	if err != nil {
+		return reference
+	}
+	resolved, err := base.Parse(reference)
+	if err != nil {
+		return reference
+	}
+	return resolved.String()
+}
@@ -0,0 +1,171 @@
+package post
+
+import (
+	"github.com/gin-gonic/gin"
+	mediaDTO "go-blog/dto/media"
+	mediaModel "go-blog/models/media"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	mediaUtil "go-blog/utils/media"
+	postUtil "go-blog/utils/post"
+	"net/http"
+)
+
+const (
+	PostMediaPath   = "/posts/:id/media"
+	PostMediaIDPath = "/posts/:id/media/:mediaId"
+	MediaNotFound   = "Media not found"
+	MediaIsCover    = "This media is the cover image of the post, change the cover image first"
+)
+
+// GetPostMedia @Summary Get the media of a post
+// @Description List the files attached to a post, including its cover image. Media of unpublished posts are only visible to their author and admins.
+// @Tags Posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {array} mediaDTO.MediaResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /v1/posts/{id}/media [get]
+func GetPostMedia(ctx *gin.Context) {
+	var post postModel.Post
+	if err := config.Db.Preload("Media.Variants").First(&post, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	if !postUtil.CanViewPost(authUtils.GetViewer(ctx), post) {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mediaDTO.ToMediaResponses(post.Media))
+}
+
+// AttachPostMedia @Summary Attach media to a post
+// @Description Attach files of the media library to a post. Authors can only attach their own uploads to their own posts.
+// @Tags Posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param request body mediaDTO.PostMediaRequest true "Media to attach"
+// @Success 200 {array} mediaDTO.MediaResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/posts/{id}/media [post]
+func AttachPostMedia(ctx *gin.Context) {
+	var request mediaDTO.PostMediaRequest
+	if !postUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	post, ok := findManagedPost(ctx)
+	if !ok {
+		return
+	}
+	currentUser, _ := authUtils.GetAuthenticatedUser(ctx)
+
+	var media []mediaModel.Media
+	if err := config.Db.Where("id IN ?", request.MediaIDs).Find(&media).Error; err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error loading media")
+		return
+	}
+	if len(media) != len(uniqueIDs(request.MediaIDs)) {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(MediaNotFound))
+		return
+	}
+	for _, m := range media {
+		if !mediaUtil.CanManageMedia(currentUser, m) {
+			ctx.JSON(http.StatusForbidden, utils.NewErrorResponse("You can only attach your own media"))
+			return
+		}
+	}
+
+	if err := config.Db.Model(&post).Association("Media").Append(&media); err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error attaching media")
+		return
+	}
+
+	if err := config.Db.Preload("Media.Variants").First(&post, post.ID).Error; err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error loading media")
+		return
+	}
+	ctx.JSON(http.StatusOK, mediaDTO.ToMediaResponses(post.Media))
+}
+
+// DetachPostMedia @Summary Detach media from a post
+// @Description Detach a file from a post, keeping it in the media library. The cover image of a post can not be detached.
+// @Tags Posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param mediaId path int true "Media ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/posts/{id}/media/{mediaId} [delete]
+func DetachPostMedia(ctx *gin.Context) {
+	post, ok := findManagedPost(ctx)
+	if !ok {
+		return
+	}
+
+	var media mediaModel.Media
+	if err := config.Db.First(&media, ctx.Param("mediaId")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(MediaNotFound))
+		return
+	}
+	if post.CoverImageID != nil && *post.CoverImageID == media.ID {
+		ctx.JSON(http.StatusConflict, utils.NewErrorResponse(MediaIsCover))
+		return
+	}
+
+	if err := config.Db.Model(&post).Association("Media").Delete(&media); err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error detaching media")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Media detached successfully"})
+}
+
+// resolveCoverImage loads the cover image requested for a post. A new cover image must be an image the current
+// user may manage, while the current cover of the post is kept as is.
+// It responds with an error and returns false when the cover image can not be used.
+func resolveCoverImage(ctx *gin.Context, post postModel.Post, coverImageID *uint) (*mediaModel.Media, bool) {
+	if coverImageID == nil {
+		return nil, true
+	}
+
+	var cover mediaModel.Media
+	if err := config.Db.Preload("Variants").First(&cover, *coverImageID).Error; err != nil || !cover.IsImage() {
+		ctx.JSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "cover_image_id", "message": "Cover image must be an image of the media library"},
+		}))
+		return nil, false
+	}
+
+	if post.CoverImageID == nil || *post.CoverImageID != cover.ID {
+		currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+		if !ok || !mediaUtil.CanManageMedia(currentUser, cover) {
+			ctx.JSON(http.StatusForbidden, utils.NewErrorResponse("You can only use your own media as cover image"))
+			return nil, false
+		}
+	}
+
+	return &cover, true
+}
+
+func uniqueIDs(ids []uint) map[uint]bool {
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return unique
+}
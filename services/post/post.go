@@ -1,45 +1,73 @@
 package post
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	postDTO "go-blog/dto/post"
 	postModel "go-blog/models/post"
 	"go-blog/services/config"
 	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
 	postUtil "go-blog/utils/post"
+	"go-blog/utils/search"
+	"gorm.io/gorm"
 	"net/http"
-	"strconv"
 	"strings"
 )
 
 const (
-	Path   = "/posts"
-	IdPath = "/posts/:id"
-	NotFound = "Post not found"
+	Path      = "/posts"
+	IdPath    = "/posts/:id"
+	SlugPath  = "/posts/slug/:slug"
+	NotFound  = "Post not found"
+	Forbidden = "You are not allowed to modify this post"
 )
 
 // CreatePost @Summary Create a new post
-// @Description Create a new blog post with optional category assignments
+// @Description Create a new blog post with optional category assignments. The content is written in Markdown or HTML, and returned both as written and as sanitized HTML. Posts are created as drafts unless another status is given.
 // @Tags Posts
 // @Accept json
 // @Produce json
 // @Param request body post.PostRequest true "Post creation request"
 // @Success 201 {object} post.PostResponse
 // @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
 // @Router /v1/posts [post]
 func CreatePost(ctx *gin.Context) {
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
 	var request postDTO.PostRequest
 	if !postUtil.BindAndValidateJSON(ctx, &request) {
 		return
 	}
 
 	postData := postModel.Post{
-		Title:   request.Title,
-		Excerpt: request.Excerpt,
-		Content: request.Content,
+		Title:         request.Title,
+		Excerpt:       request.Excerpt,
+		Content:       request.Content,
+		ContentFormat: request.ContentFormat,
+		AuthorID:      &currentUser.ID,
+	}
+
+	if err := postUtil.ApplyPostStatus(&postData, request.Status, request.PublishedAt); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "published_at", "message": err.Error()},
+		}))
+		return
 	}
 
+	coverImage, ok := resolveCoverImage(ctx, postData, request.CoverImageID)
+	if !ok {
+		return
+	}
+	postData.CoverImageID = request.CoverImageID
+
 	if len(request.CategoryIDs) > 0 {
 		var categories []postModel.Category
 		if err := config.Db.Where("id IN ?", request.CategoryIDs).Find(&categories).Error; err != nil {
@@ -49,55 +77,100 @@ func CreatePost(ctx *gin.Context) {
 		postData.Categories = categories
 	}
 
-	if err := config.Db.Create(&postData).Error; err != nil {
+	// The post, its new tags, its cover image and its first revision are saved together
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		tags, err := postUtil.GetOrCreateTags(tx, request.Tags)
+		if err != nil {
+			return err
+		}
+		postData.Tags = tags
+
+		if err := postUtil.SaveWithUniqueSlug(postModel.SlugEntityPost, request.Title, "post", 0, func(slug string) error {
+			postData.Slug = slug
+			return tx.Create(&postData).Error
+		}); err != nil {
+			return err
+		}
+		if coverImage != nil {
+			if err := tx.Model(&postData).Association("Media").Append(coverImage); err != nil {
+				return err
+			}
+		}
+		_, err = postUtil.RecordRevision(tx, postData, &currentUser.ID, nil)
+		return err
+	})
+	if err != nil {
 		postUtil.HandleDatabaseError(ctx, "Error saving post to the database")
 		return
 	}
+	postData.Author = &currentUser
+	postData.CoverImage = coverImage
 
 	response := postDTO.ToPostResponse(postData)
+	postUtil.SetETag(ctx, postData.Version)
 	ctx.JSON(http.StatusCreated, response)
 }
 
 // GetAllPosts @Summary Get paginated list of posts
-// @Description Retrieve a paginated list of blog posts with optional category filtering
+// @Description Retrieve a paginated list of published blog posts with optional category, author, date and full-text filtering. Authenticated authors also see their own unpublished posts.
 // @Tags Posts
 // @Produce json
 // @Param page query int false "Page number (default is 1)"
-// @Param limit query int false "Items per page (default is 10)" 
+// @Param limit query int false "Items per page (default is 10)"
+// @Param q query string false "Full-text search on title, excerpt and content; results are ranked by relevance"
 // @Param category_ids query string false "Comma-separated list of category IDs to filter by"
+// @Param tags query string false "Comma-separated list of tag names or slugs to filter by"
+// @Param tags_match query string false "Whether posts must have any or all of the tags (default is any)" Enums(any, all)
+// @Param author_id query int false "Author ID to filter by"
+// @Param from query string false "Only posts published from this date (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Only posts published before this time (RFC 3339) or until the end of this day (YYYY-MM-DD)"
+// @Param status query string false "Post status to filter by" Enums(DRAFT, IN_REVIEW, SCHEDULED, PUBLISHED, ARCHIVED)
 // @Success 200 {object} utils.PaginatedResponse[post.PostResponse]
-// @Failure 400 {object} utils.ErrorResponse "Invalid category_ids"
+// @Failure 400 {object} utils.ErrorResponse "Invalid filters"
 // @Failure 404 {object} utils.ErrorResponse "Page not found"
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /v1/posts [get]
 func GetAllPosts(ctx *gin.Context) {
 	page, limit, offset := postUtil.ParsePaginationParams(ctx)
 
-	// 1. Get category_ids from a query string
-	categoryIDsParam := ctx.Query("category_ids")
-	var categoryIDs []uint
-	if categoryIDsParam != "" {
-		parts := strings.Split(categoryIDsParam, ",")
-		for _, part := range parts {
-			id, err := strconv.Atoi(strings.TrimSpace(part))
-			if err != nil {
-				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category_ids"})
-				return
-			}
-			categoryIDs = append(categoryIDs, uint(id))
+	// 1. Get the filters from the query string
+	filters, ok := parsePostQuery(ctx)
+	if !ok {
+		return
+	}
+
+	filters.Status = ctx.Query("status")
+	if filters.Status != "" && !postUtil.IsValidPostStatus(filters.Status) {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid status"))
+		return
+	}
+
+	// 2. Delegate to the search backend when a search text is given
+	if text := strings.TrimSpace(ctx.Query("q")); text != "" {
+		filters.Text = text
+		filters.Limit = limit
+		filters.Offset = offset
+		hits, total, err := search.CurrentBackend().SearchPosts(filters)
+		if err != nil {
+			postUtil.HandleDatabaseError(ctx, "Error searching posts")
+			return
+		}
+		if !checkPage(ctx, page, limit, total) {
+			return
 		}
+		response := make([]postDTO.PostResponse, 0, len(hits))
+		for _, hit := range hits {
+			response = append(response, postDTO.ToPostResponse(hit.Post))
+		}
+		ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+		return
 	}
 
 	var posts []postModel.Post
-	query := config.Db.Model(&postModel.Post{}).Preload("Categories")
+	query := config.Db.Model(&postModel.Post{}).Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants")
 
-	// 2. Apply category filter if requested
-	if len(categoryIDs) > 0 {
-		query = query.
-			Joins("JOIN post_categories pc ON pc.post_id = posts.id").
-			Where("pc.category_id IN ?", categoryIDs).
-			Group("posts.id")
-	}
+	// 3. Apply the other filters if requested
+	query = filterPosts(query, filters)
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -128,114 +201,354 @@ func GetAllPosts(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
 }
 
+// filterPosts restricts the query to the posts the viewer may see, matching the filters of a post listing
+func filterPosts(query *gorm.DB, filters search.PostQuery) *gorm.DB {
+	query = postUtil.ApplyPostVisibility(query, filters.Viewer)
+	if filters.Status != "" {
+		query = query.Where("posts.status = ?", filters.Status)
+	}
+
+	if len(filters.CategoryIDs) > 0 {
+		query = query.
+			Joins("JOIN post_categories pc ON pc.post_id = posts.id").
+			Where("pc.category_id IN ?", filters.CategoryIDs).
+			Group("posts.id")
+	}
+	query = postUtil.ApplyTagFilter(query, filters.TagSlugs, filters.AllTags)
+	if filters.AuthorID != nil {
+		query = query.Where("posts.author_id = ?", *filters.AuthorID)
+	}
+	if filters.From != nil {
+		query = query.Where("COALESCE(posts.published_at, posts.created_at) >= ?", *filters.From)
+	}
+	if filters.Before != nil {
+		query = query.Where("COALESCE(posts.published_at, posts.created_at) < ?", *filters.Before)
+	}
+	return query
+}
+
 // GetPostByID @Summary Get post by ID
-// @Description Retrieve a specific post by its ID
+// @Description Retrieve a specific post by its ID. Unpublished posts are only visible to their author and admins. The ETag header holds the version of the post.
 // @Tags Posts
 // @Produce json
 // @Param id path int true "Post ID"
+// @Param If-None-Match header string false "ETag of the cached version"
 // @Success 200 {object} post.PostResponse
+// @Success 304 {string} string "Not modified"
 // @Failure 404 {object} utils.ErrorResponse
 // @Router /v1/posts/{id} [get]
 func GetPostByID(ctx *gin.Context) {
 	var model postModel.Post
 	id := ctx.Param("id")
 
-	if err := config.Db.Preload("Categories").First(&model, id).Error; err != nil {
+	if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants").First(&model, id).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	if !postUtil.CanViewPost(authUtils.GetViewer(ctx), model) {
 		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
 		return
 	}
 
+	if postUtil.NotModified(ctx, model.Version) {
+		return
+	}
+
 	response := postDTO.ToPostResponse(model)
+	postUtil.SetETag(ctx, model.Version)
 	ctx.JSON(http.StatusOK, response)
 }
 
+// GetPostBySlug @Summary Get post by slug
+// @Description Retrieve a specific post by its slug. Old slugs are redirected to the current one. The ETag header holds the version of the post.
+// @Tags Posts
+// @Produce json
+// @Param slug path string true "Post slug"
+// @Param If-None-Match header string false "ETag of the cached version"
+// @Success 200 {object} post.PostResponse
+// @Success 304 {string} string "Not modified"
+// @Success 301 {string} string "Moved permanently to the current slug"
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /v1/posts/slug/{slug} [get]
+func GetPostBySlug(ctx *gin.Context) {
+	var model postModel.Post
+	slug := ctx.Param("slug")
+
+	if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants").Where("slug = ?", slug).First(&model).Error; err != nil {
+		postID, err := postUtil.FindSlugRedirect(postModel.SlugEntityPost, slug)
+		if err != nil || config.Db.First(&model, postID).Error != nil || !postUtil.CanViewPost(authUtils.GetViewer(ctx), model) {
+			ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+			return
+		}
+		ctx.Redirect(http.StatusMovedPermanently, strings.TrimSuffix(ctx.Request.URL.Path, slug)+model.Slug)
+		return
+	}
+
+	if !postUtil.CanViewPost(authUtils.GetViewer(ctx), model) {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	if postUtil.NotModified(ctx, model.Version) {
+		return
+	}
+
+	postUtil.SetETag(ctx, model.Version)
+	ctx.JSON(http.StatusOK, postDTO.ToPostResponse(model))
+}
+
 // UpdatePost @Summary Update a post
-// @Description Update an existing post by its ID
+// @Description Update an existing post by its ID, saving the result as a new revision. Authors can only update their own posts. The version the update is based on must be sent in an If-Match header or the version field.
 // @Tags Posts
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID"
+// @Param If-Match header string false "ETag of the version the update is based on"
 // @Param request body post.PostRequest true "Post update request"
 // @Success 200 {object} post.PostResponse
 // @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ConflictResponse "The version field is not the current version"
+// @Failure 412 {object} utils.ConflictResponse "The If-Match header is not the current version"
+// @Failure 428 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
 // @Router /v1/posts/{id} [put]
 func UpdatePost(ctx *gin.Context) {
 	id := ctx.Param("id")
 
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
 	var request postDTO.PostRequest
 	if !postUtil.BindAndValidateJSON(ctx, &request) {
 		return
 	}
 
+	precondition, ok := postUtil.RequirePrecondition(ctx, request.Version)
+	if !ok {
+		return
+	}
+
 	var post postModel.Post
-	if err := config.Db.Preload("Categories").First(&post, id).Error; err != nil {
+	if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants").First(&post, id).Error; err != nil {
 		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
 		return
 	}
 
-	post.Title = request.Title
-	post.Excerpt = request.Excerpt
-	post.Content = request.Content
+	if !postUtil.CanManagePost(currentUser, post) {
+		ctx.JSON(http.StatusForbidden, utils.NewErrorResponse(Forbidden))
+		return
+	}
 
-	// Update associated categories
-	if len(request.CategoryIDs) > 0 {
-		var categories []postModel.Category
-		if err := config.Db.Where("id IN ?", request.CategoryIDs).Find(&categories).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error loading categories"})
-			return
-		}
-		// Replace existing associations
-		if err := config.Db.Model(&post).Association("Categories").Replace(&categories); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating categories"})
-			return
-		}
-	} else {
-		// Clear categories if none are sent
-		if err := config.Db.Model(&post).Association("Categories").Clear(); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error clearing categories"})
-			return
-		}
+	if !precondition.Matches(post.Version) {
+		postUtil.RespondVersionConflict(ctx, precondition, post.Version, postDTO.ToPostResponse(post))
+		return
 	}
 
-	if err := config.Db.Save(&post).Error; err != nil {
-		postUtil.HandleDatabaseError(ctx, "Error updating post")
+	if !applyPostUpdate(ctx, &post, request, precondition, &currentUser.ID, nil) {
 		return
 	}
 
 	response := postDTO.ToPostResponse(post)
+	postUtil.SetETag(ctx, post.Version)
 	ctx.JSON(http.StatusOK, response)
 }
 
 // DeletePost @Summary Delete a post
-// @Description Delete a post by its ID
+// @Description Delete a post by its ID. Authors can only delete their own posts. The version the deletion is based on must be sent in an If-Match header or the version query parameter.
 // @Tags Posts
 // @Produce json
 // @Param id path int true "Post ID"
+// @Param If-Match header string false "ETag of the version the deletion is based on"
+// @Param version query int false "Version the deletion is based on, when no If-Match header is sent"
 // @Success 200 {object} string "Post deleted successfully"
+// @Failure 403 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ConflictResponse "The version parameter is not the current version"
+// @Failure 412 {object} utils.ConflictResponse "The If-Match header is not the current version"
+// @Failure 428 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
 // @Router /v1/posts/{id} [delete]
 func DeletePost(ctx *gin.Context) {
 	id := ctx.Param("id")
 
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	precondition, ok := postUtil.RequirePrecondition(ctx, nil)
+	if !ok {
+		return
+	}
+
 	var post postModel.Post
-	if err := config.Db.First(&post, id).Error; err != nil {
+	if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants").First(&post, id).Error; err != nil {
 		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
 		return
 	}
 
-	// Clear associations manually if needed
-	if err := config.Db.Model(&post).Association("Categories").Clear(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error clearing categories"})
+	if !postUtil.CanManagePost(currentUser, post) {
+		ctx.JSON(http.StatusForbidden, utils.NewErrorResponse(Forbidden))
 		return
 	}
 
-	if err := config.Db.Delete(&post).Error; err != nil {
-		postUtil.HandleDatabaseError(ctx, "Error deleting post")
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := claimPostVersion(tx, &post, precondition); err != nil {
+			return err
+		}
+		// Clear associations manually if needed
+		if err := tx.Model(&post).Association("Categories").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(&post).Association("Tags").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&post).Error
+	})
+	if err != nil {
+		respondPostError(ctx, post.ID, precondition, err, "Error deleting post")
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
 }
+
+// applyPostUpdate saves the changes of the request to the post and records them as a new revision.
+// It responds with an error and returns false when the update fails, or when the post changed since its expected version.
+func applyPostUpdate(ctx *gin.Context, post *postModel.Post, request postDTO.PostRequest, precondition postUtil.Precondition, editorID *uint, restoredFrom *int) bool {
+	original := *post
+
+	// Regenerate the slug when the title changes, keeping the old one as a redirect
+	oldSlug := post.Slug
+	renamed := request.Title != post.Title || post.Slug == ""
+
+	post.Title = request.Title
+	post.Excerpt = request.Excerpt
+	post.Content = request.Content
+	if request.ContentFormat != "" {
+		post.ContentFormat = request.ContentFormat
+	}
+
+	if err := postUtil.ApplyPostStatus(post, request.Status, request.PublishedAt); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "published_at", "message": err.Error()},
+		}))
+		return false
+	}
+
+	coverImage, ok := resolveCoverImage(ctx, *post, request.CoverImageID)
+	if !ok {
+		return false
+	}
+	post.CoverImageID = request.CoverImageID
+	post.CoverImage = coverImage
+
+	var categories []postModel.Category
+	if len(request.CategoryIDs) > 0 {
+		if err := config.Db.Where("id IN ?", request.CategoryIDs).Find(&categories).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error loading categories"})
+			return false
+		}
+	}
+
+	// The version is claimed in the transaction saving the changes and their revision, so that a failed update
+	// leaves the post as it was, and the revisions of concurrent updates are numbered one after the other
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := claimPostVersion(tx, post, precondition); err != nil {
+			return err
+		}
+
+		// Keep the state of posts created before revisions were recorded
+		if err := postUtil.EnsureInitialRevision(tx, original); err != nil {
+			return err
+		}
+
+		// Replace existing associations, clearing the categories if none are sent
+		if len(categories) > 0 {
+			if err := tx.Model(post).Association("Categories").Replace(&categories); err != nil {
+				return err
+			}
+		} else if err := tx.Model(post).Association("Categories").Clear(); err != nil {
+			return err
+		}
+		// Create the new tags on the fly
+		tags, err := postUtil.GetOrCreateTags(tx, request.Tags)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(post).Association("Tags").Replace(&tags); err != nil {
+			return err
+		}
+
+		// The version was incremented by the claim, so it is not written again
+		save := func(slug string) error {
+			post.Slug = slug
+			return tx.Omit("version").Save(post).Error
+		}
+		if renamed {
+			err = postUtil.SaveWithUniqueSlug(postModel.SlugEntityPost, request.Title, "post", post.ID, save)
+		} else {
+			err = save(post.Slug)
+		}
+		if err != nil {
+			return err
+		}
+
+		// The cover image is also listed with the media of the post
+		if coverImage != nil {
+			if err := tx.Model(post).Association("Media").Append(coverImage); err != nil {
+				return err
+			}
+		}
+
+		if oldSlug != post.Slug {
+			if err := postUtil.RecordSlugRedirect(tx, postModel.SlugEntityPost, oldSlug, post.ID); err != nil {
+				return err
+			}
+		}
+
+		_, err = postUtil.RecordRevision(tx, *post, editorID, restoredFrom)
+		return err
+	})
+	if err != nil {
+		respondPostError(ctx, post.ID, precondition, err, "Error updating post")
+		return false
+	}
+
+	return true
+}
+
+// claimPostVersion increments the version of the post within the transaction changing it. It returns
+// postUtil.ErrVersionConflict when the post is no longer at the expected version.
+func claimPostVersion(tx *gorm.DB, post *postModel.Post, precondition postUtil.Precondition) error {
+	if err := postUtil.ClaimVersion(tx, &postModel.Post{}, post.ID, post.Version, precondition); err != nil {
+		return err
+	}
+	post.Version++
+	return nil
+}
+
+// respondPostError responds to a failed change of the post: with the current post when it is no longer at the
+// expected version, or with a database error
+func respondPostError(ctx *gin.Context, postID uint, precondition postUtil.Precondition, err error, message string) {
+	if !errors.Is(err, postUtil.ErrVersionConflict) {
+		postUtil.HandleDatabaseError(ctx, message)
+		return
+	}
+
+	var current postModel.Post
+	if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants").First(&current, postID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+	postUtil.RespondVersionConflict(ctx, precondition, current.Version, postDTO.ToPostResponse(current))
+}
@@ -0,0 +1,244 @@
+package post
+
+import (
+	"github.com/gin-gonic/gin"
+	postDTO "go-blog/dto/post"
+	mediaModel "go-blog/models/media"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"go-blog/utils/diff"
+	postUtil "go-blog/utils/post"
+	"net/http"
+	"strconv"
+)
+
+const (
+	RevisionsPath       = "/posts/:id/revisions"
+	RevisionDiffPath    = "/posts/:id/revisions/diff"
+	RevisionNumberPath  = "/posts/:id/revisions/:number"
+	RevisionRestorePath = "/posts/:id/revisions/:number/restore"
+	RevisionNotFound    = "Revision not found"
+)
+
+// GetPostRevisions @Summary Get the revisions of a post
+// @Description List the revisions of a post, most recent first. Authors can only see the revisions of their own posts.
+// @Tags Posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Items per page (default is 10)"
+// @Success 200 {object} utils.PaginatedResponse[post.PostRevisionSummaryResponse]
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/posts/{id}/revisions [get]
+func GetPostRevisions(ctx *gin.Context) {
+	post, ok := findManagedPost(ctx)
+	if !ok {
+		return
+	}
+
+	page, limit, offset := postUtil.ParsePaginationParams(ctx)
+	query := config.Db.Model(&postModel.PostRevision{}).Where("post_id = ?", post.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error retrieving total count")
+		return
+	}
+
+	var revisions []postModel.PostRevision
+	if err := query.Preload("Editor").Order("number DESC").Limit(limit).Offset(offset).Find(&revisions).Error; err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error retrieving revisions")
+		return
+	}
+
+	response := make([]postDTO.PostRevisionSummaryResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		response = append(response, postDTO.ToPostRevisionSummaryResponse(revision))
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+}
+
+// GetPostRevision @Summary Get a revision of a post
+// @Description Retrieve the full snapshot saved by a revision of a post
+// @Tags Posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param number path int true "Revision number"
+// @Success 200 {object} post.PostRevisionResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/posts/{id}/revisions/{number} [get]
+func GetPostRevision(ctx *gin.Context) {
+	post, ok := findManagedPost(ctx)
+	if !ok {
+		return
+	}
+
+	revision, ok := findRevision(ctx, post.ID, ctx.Param("number"))
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, postDTO.ToPostRevisionResponse(revision))
+}
+
+// DiffPostRevisions @Summary Compare two revisions of a post
+// @Description Show the changes between two revisions of a post. The title and excerpt are compared word by word, the content line by line unless mode is word.
+// @Tags Posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param from query int true "Number of the older revision"
+// @Param to query int false "Number of the newer revision (default is the latest)"
+// @Param mode query string false "How to compare the content (default is line)" Enums(line, word)
+// @Success 200 {object} post.PostRevisionDiffResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/posts/{id}/revisions/diff [get]
+func DiffPostRevisions(ctx *gin.Context) {
+	post, ok := findManagedPost(ctx)
+	if !ok {
+		return
+	}
+
+	mode := ctx.DefaultQuery("mode", "line")
+	if mode != "line" && mode != "word" {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid mode, expected line or word"))
+		return
+	}
+
+	if ctx.Query("from") == "" {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("The from revision is required"))
+		return
+	}
+	from, ok := findRevision(ctx, post.ID, ctx.Query("from"))
+	if !ok {
+		return
+	}
+
+	var to postModel.PostRevision
+	if ctx.Query("to") != "" {
+		if to, ok = findRevision(ctx, post.ID, ctx.Query("to")); !ok {
+			return
+		}
+	} else if err := config.Db.Where("post_id = ?", post.ID).Order("number DESC").First(&to).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(RevisionNotFound))
+		return
+	}
+
+	content := diff.Lines(from.Content, to.Content)
+	if mode == "word" {
+		content = diff.Words(from.Content, to.Content)
+	}
+
+	categoriesAdded, categoriesRemoved := postUtil.CompareSets(from.CategoryIDs, to.CategoryIDs)
+	tagsAdded, tagsRemoved := postUtil.CompareSets(from.Tags, to.Tags)
+
+	ctx.JSON(http.StatusOK, postDTO.PostRevisionDiffResponse{
+		From:              from.Number,
+		To:                to.Number,
+		Title:             diff.Words(from.Title, to.Title),
+		Excerpt:           diff.Words(from.Excerpt, to.Excerpt),
+		Content:           content,
+		StatusFrom:        from.Status,
+		StatusTo:          to.Status,
+		CategoriesAdded:   categoriesAdded,
+		CategoriesRemoved: categoriesRemoved,
+		TagsAdded:         tagsAdded,
+		TagsRemoved:       tagsRemoved,
+	})
+}
+
+// RestorePostRevision @Summary Restore a revision of a post
+// @Description Bring back the title, excerpt, content, categories and tags of an earlier revision, saved as a new revision. The status of the post is kept.
+// @Tags Posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param number path int true "Revision number"
+// @Param If-Match header string false "ETag of the version the restore is based on"
+// @Success 200 {object} post.PostResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ConflictResponse
+// @Failure 412 {object} utils.ConflictResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/posts/{id}/revisions/{number}/restore [post]
+func RestorePostRevision(ctx *gin.Context) {
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse("User not found in context"))
+		return
+	}
+
+	post, ok := findManagedPost(ctx)
+	if !ok {
+		return
+	}
+
+	revision, ok := findRevision(ctx, post.ID, ctx.Param("number"))
+	if !ok {
+		return
+	}
+
+	request := postDTO.PostRequest{
+		Title:         revision.Title,
+		Excerpt:       revision.Excerpt,
+		Content:       revision.Content,
+		ContentFormat: revision.ContentFormat,
+		CategoryIDs:   revision.CategoryIDs,
+		Tags:          revision.Tags,
+		CoverImageID:  revision.CoverImageID,
+	}
+	// The cover image may have been deleted from the media library since
+	if request.CoverImageID != nil && config.Db.First(&mediaModel.Media{}, *request.CoverImageID).Error != nil {
+		request.CoverImageID = nil
+	}
+	// Without an If-Match header, the revision is restored over the post as loaded here
+	precondition, ok := postUtil.ReadPrecondition(ctx, nil)
+	if !ok {
+		precondition = postUtil.Precondition{Version: post.Version}
+	}
+	if !applyPostUpdate(ctx, &post, request, precondition, &currentUser.ID, &revision.Number) {
+		return
+	}
+
+	postUtil.SetETag(ctx, post.Version)
+	ctx.JSON(http.StatusOK, postDTO.ToPostResponse(post))
+}
+
+// findManagedPost loads the post targeted by the request, when the current user may manage it
+func findManagedPost(ctx *gin.Context) (postModel.Post, bool) {
+	var post postModel.Post
+	if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants").First(&post, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return post, false
+	}
+
+	currentUser, ok := authUtils.GetAuthenticatedUser(ctx)
+	if !ok || !postUtil.CanManagePost(currentUser, post) {
+		ctx.JSON(http.StatusForbidden, utils.NewErrorResponse(Forbidden))
+		return post, false
+	}
+
+	return post, true
+}
+
+func findRevision(ctx *gin.Context, postID uint, number string) (postModel.PostRevision, bool) {
+	var revision postModel.PostRevision
+	n, err := strconv.Atoi(number)
+	if err != nil || config.Db.Preload("Editor").Where("post_id = ? AND number = ?", postID, n).First(&revision).Error != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(RevisionNotFound))
+		return revision, false
+	}
+	return revision, true
+}
@@ -0,0 +1,183 @@
+package post
+
+import (
+	"github.com/gin-gonic/gin"
+	postDTO "go-blog/dto/post"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	postUtil "go-blog/utils/post"
+	"go-blog/utils/search"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	SearchPath = "/search"
+
+	searchTypePosts    = "posts"
+	searchTypeComments = "comments"
+)
+
+// Search @Summary Search posts or comments
+// @Description Full-text search over post titles, excerpts and contents (or approved comments), ranked by relevance with highlighted snippets
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search text"
+// @Param type query string false "What to search (default is posts)" Enums(posts, comments)
+// @Param category_ids query string false "Comma-separated list of category IDs to filter posts by"
+// @Param tags query string false "Comma-separated list of tag names or slugs to filter posts by"
+// @Param tags_match query string false "Whether posts must have any or all of the tags (default is any)" Enums(any, all)
+// @Param author_id query int false "Author ID to filter posts by"
+// @Param from query string false "Only posts published from this date (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Only posts published before this time (RFC 3339) or until the end of this day (YYYY-MM-DD)"
+// @Param post_id query int false "Post ID to filter comments by"
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Items per page (default is 10)"
+// @Success 200 {object} utils.PaginatedResponse[post.PostSearchResult]
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /v1/search [get]
+func Search(ctx *gin.Context) {
+	text := strings.TrimSpace(ctx.Query("q"))
+	if text == "" {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Missing search query"))
+		return
+	}
+
+	switch ctx.DefaultQuery("type", searchTypePosts) {
+	case searchTypePosts:
+		searchPosts(ctx, text)
+	case searchTypeComments:
+		searchComments(ctx, text)
+	default:
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid search type"))
+	}
+}
+
+func searchPosts(ctx *gin.Context, text string) {
+	page, limit, offset := postUtil.ParsePaginationParams(ctx)
+
+	query, ok := parsePostQuery(ctx)
+	if !ok {
+		return
+	}
+	query.Text = text
+	query.Limit = limit
+	query.Offset = offset
+
+	hits, total, err := search.CurrentBackend().SearchPosts(query)
+	if err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error searching posts")
+		return
+	}
+	if !checkPage(ctx, page, limit, total) {
+		return
+	}
+
+	terms := search.Terms(text)
+	response := make([]postDTO.PostSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		response = append(response, postDTO.PostSearchResult{
+			Post:  postDTO.ToPostResponse(hit.Post),
+			Score: hit.Score,
+			Highlights: postDTO.PostHighlights{
+				Title:   search.Highlight(hit.Post.Title, terms),
+				Excerpt: search.Highlight(hit.Post.Excerpt, terms),
+				Content: search.Highlight(hit.Post.Content, terms),
+			},
+		})
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+}
+
+func searchComments(ctx *gin.Context, text string) {
+	page, limit, offset := postUtil.ParsePaginationParams(ctx)
+
+	query := search.CommentQuery{Text: text, Viewer: authUtils.GetViewer(ctx), Limit: limit, Offset: offset}
+	if postIDParam := ctx.Query("post_id"); postIDParam != "" {
+		postID, err := strconv.ParseUint(postIDParam, 10, 0)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid post_id"))
+			return
+		}
+		id := uint(postID)
+		query.PostID = &id
+	}
+
+	hits, total, err := search.CurrentBackend().SearchComments(query)
+	if err != nil {
+		postUtil.HandleDatabaseError(ctx, "Error searching comments")
+		return
+	}
+	if !checkPage(ctx, page, limit, total) {
+		return
+	}
+
+	terms := search.Terms(text)
+	response := make([]postDTO.CommentSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		response = append(response, postDTO.CommentSearchResult{
+			Comment:   postDTO.ToCommentResponse(hit.Comment),
+			Score:     hit.Score,
+			Highlight: search.Highlight(hit.Comment.Content, terms),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+}
+
+// parsePostQuery reads the post filters shared by the post listing and the search endpoint
+func parsePostQuery(ctx *gin.Context) (search.PostQuery, bool) {
+	query := search.PostQuery{Viewer: authUtils.GetViewer(ctx)}
+
+	categoryIDs, err := postUtil.ParseUintList(ctx.Query("category_ids"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid category_ids"))
+		return query, false
+	}
+	query.CategoryIDs = categoryIDs
+
+	query.TagSlugs = postUtil.ParseTagSlugs(ctx.Query("tags"))
+	switch ctx.DefaultQuery("tags_match", "any") {
+	case "any":
+	case "all":
+		query.AllTags = true
+	default:
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid tags_match, expected any or all"))
+		return query, false
+	}
+
+	if authorIDParam := ctx.Query("author_id"); authorIDParam != "" {
+		authorID, err := strconv.ParseUint(authorIDParam, 10, 0)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid author_id"))
+			return query, false
+		}
+		id := uint(authorID)
+		query.AuthorID = &id
+	}
+
+	if query.From, err = postUtil.ParseDateParam(ctx.Query("from")); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid from date"))
+		return query, false
+	}
+	if query.Before, err = postUtil.ParseDateEndParam(ctx.Query("to")); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Invalid to date"))
+		return query, false
+	}
+
+	return query, true
+}
+
+// checkPage responds with a 404 when the requested page is past the last one
+func checkPage(ctx *gin.Context, page, limit int, total int64) bool {
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	if totalPages > 0 && page > totalPages {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse("Page not found"))
+		return false
+	}
+	return true
+}
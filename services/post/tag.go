@@ -0,0 +1,210 @@
+package post
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	tagDTO "go-blog/dto/post"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	"go-blog/utils"
+	tagUtil "go-blog/utils/post"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+const (
+	TagPath      = "/tags"
+	TagIDPath    = "/tags/:id"
+	TagMergePath = "/tags/:id/merge"
+	TagNotFound  = "Tag not found"
+)
+
+type tagWithCount struct {
+	postModel.Tag
+	PostCount int64
+}
+
+// GetAllTags @Summary Get paginated list of tags
+// @Description Retrieve a paginated list of tags with the number of published posts using each of them, most used first
+// @Tags Tags
+// @Produce json
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Items per page (default is 10)"
+// @Success 200 {object} utils.PaginatedResponse[post.TagResponse]
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /v1/tags [get]
+func GetAllTags(ctx *gin.Context) {
+	page, limit, offset := tagUtil.ParsePaginationParams(ctx)
+
+	var total int64
+	if err := config.Db.Model(&postModel.Tag{}).Count(&total).Error; err != nil {
+		tagUtil.HandleDatabaseError(ctx, "Error retrieving total count")
+		return
+	}
+	if !checkPage(ctx, page, limit, total) {
+		return
+	}
+
+	var tags []tagWithCount
+	if err := config.Db.Model(&postModel.Tag{}).
+		Select("tags.*, COUNT(posts.id) AS post_count").
+		Joins("LEFT JOIN post_tags pt ON pt.tag_id = tags.id").
+		Joins("LEFT JOIN posts ON posts.id = pt.post_id AND posts.status = ? AND posts.deleted_at IS NULL", postModel.PostStatusPublished).
+		Group("tags.id").
+		Order("post_count DESC, tags.name ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&tags).Error; err != nil {
+		tagUtil.HandleDatabaseError(ctx, "Error retrieving tags from the database")
+		return
+	}
+
+	response := make([]tagDTO.TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, tagDTO.ToTagResponse(tag.Tag, tag.PostCount))
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+}
+
+// RenameTag @Summary Rename a tag
+// @Description Rename an existing tag and regenerate its slug
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param request body post.TagRequest true "Tag rename request"
+// @Success 200 {object} post.TagResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/tags/{id} [put]
+func RenameTag(ctx *gin.Context) {
+	var request tagDTO.TagRequest
+	if !tagUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	var tag postModel.Tag
+	if err := config.Db.First(&tag, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(TagNotFound))
+		return
+	}
+
+	slug := tagUtil.Slugify(request.Name)
+	if slug == "" {
+		ctx.JSON(http.StatusBadRequest, utils.NewValidationErrorResponse([]map[string]string{
+			{"field": "name", "message": "name must contain at least one letter or digit"},
+		}))
+		return
+	}
+
+	var existing postModel.Tag
+	err := config.Db.Where("slug = ? AND id <> ?", slug, tag.ID).First(&existing).Error
+	if err == nil {
+		ctx.JSON(http.StatusConflict, utils.NewErrorResponse("Another tag already uses this name, merge the tags instead"))
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		tagUtil.HandleDatabaseError(ctx, "Error checking tag name")
+		return
+	}
+
+	tag.Name = request.Name
+	tag.Slug = slug
+	if err := config.Db.Save(&tag).Error; err != nil {
+		tagUtil.HandleDatabaseError(ctx, "Error updating tag")
+		return
+	}
+
+	postCount := config.Db.Model(&tag).Association("Posts").Count()
+	ctx.JSON(http.StatusOK, tagDTO.ToTagResponse(tag, postCount))
+}
+
+// MergeTag @Summary Merge a tag into another one
+// @Description Move every post of the tag to the target tag, then delete the merged tag
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param id path int true "ID of the tag to merge"
+// @Param request body post.TagMergeRequest true "Target tag"
+// @Success 200 {object} post.TagResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/tags/{id}/merge [post]
+func MergeTag(ctx *gin.Context) {
+	var request tagDTO.TagMergeRequest
+	if !tagUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	var source postModel.Tag
+	if err := config.Db.First(&source, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(TagNotFound))
+		return
+	}
+	if source.ID == request.TargetID {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("Cannot merge a tag into itself"))
+		return
+	}
+
+	var target postModel.Tag
+	if err := config.Db.First(&target, request.TargetID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse("Target tag not found"))
+		return
+	}
+
+	err := config.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(
+			"INSERT IGNORE INTO post_tags (post_id, tag_id) SELECT post_id, ? FROM post_tags WHERE tag_id = ?",
+			target.ID, source.ID,
+		).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		tagUtil.HandleDatabaseError(ctx, "Error merging tags")
+		return
+	}
+
+	postCount := config.Db.Model(&target).Association("Posts").Count()
+	ctx.JSON(http.StatusOK, tagDTO.ToTagResponse(target, postCount))
+}
+
+// DeleteTag @Summary Delete a tag
+// @Description Delete a tag and remove it from every post
+// @Tags Tags
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/tags/{id} [delete]
+func DeleteTag(ctx *gin.Context) {
+	var tag postModel.Tag
+	if err := config.Db.First(&tag, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(TagNotFound))
+		return
+	}
+
+	if err := config.Db.Model(&tag).Association("Posts").Clear(); err != nil {
+		tagUtil.HandleDatabaseError(ctx, "Error removing tag from posts")
+		return
+	}
+	if err := config.Db.Delete(&tag).Error; err != nil {
+		tagUtil.HandleDatabaseError(ctx, "Error deleting tag")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"fmt"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// PublishScheduledPosts publishes the scheduled posts whose publication date has passed.
+func PublishScheduledPosts() error {
+	result := config.Db.Model(&postModel.Post{}).
+		Where("status = ? AND published_at <= ?", postModel.PostStatusScheduled, time.Now()).
+		Updates(map[string]interface{}{"status": postModel.PostStatusPublished, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return fmt.Errorf("error publishing scheduled posts: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("[CRON] %d scheduled post(s) published", result.RowsAffected)
+	}
+	return nil
+}
@@ -6,7 +6,12 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 	_ "go-blog/docs"
 	"go-blog/services/auth"
+	"go-blog/services/config"
+	"go-blog/services/media"
 	"go-blog/services/post"
+	"go-blog/services/storage"
+	"go-blog/services/user"
+	"go-blog/utils/ratelimit"
 )
 
 func InitRoutes() *gin.Engine {
@@ -15,8 +20,26 @@ func InitRoutes() *gin.Engine {
 	// Swagger route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Public keys verifying the access tokens
+	router.GET(auth.JWKSPath, auth.JWKS)
+
+	// Syndication feeds of the published posts
+	feeds := router.Group("/", ratelimit.Middleware("global", config.RateLimitGlobal))
+	feeds.GET(post.FeedPath, post.GetFeed)
+	feeds.GET(post.CategoryFeedPath, post.GetCategoryFeed)
+	feeds.GET(post.AuthorFeedPath, post.GetAuthorFeed)
+
+	// Uploaded files, when they are not served by an object storage
+	if local, ok := storage.Default().(*storage.LocalStorage); ok {
+		files := router.Group(config.MediaPath, func(ctx *gin.Context) {
+			ctx.Header("X-Content-Type-Options", "nosniff")
+			ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+		})
+		files.StaticFS("/", gin.Dir(local.Root(), false))
+	}
+
 	// Main group with prefix /v1
-	v1 := router.Group("/v1")
+	v1 := router.Group("/v1", ratelimit.Middleware("global", config.RateLimitGlobal))
 
 	setupPublicRoutes(v1)
 	setupProtectedRoutes(v1)
@@ -25,25 +48,61 @@ func InitRoutes() *gin.Engine {
 }
 
 func setupPublicRoutes(v1 *gin.RouterGroup) {
-	v1.POST("/register", auth.Register)
-	v1.POST("/login", auth.Login)
-	v1.POST("/refresh-token", auth.RefreshToken)
+	// Auth routes, with a stricter rate limit against credential stuffing and email flooding
+	authRoutes := v1.Group("/", ratelimit.Middleware("auth", config.RateLimitAuth))
+	authRoutes.POST("/register", auth.Register)
+	authRoutes.POST("/login", auth.Login)
+	authRoutes.POST(auth.TwoFactorLoginPath, auth.LoginTwoFactor)
+	authRoutes.POST("/refresh-token", auth.RefreshToken)
+	authRoutes.POST("/forgot-password", auth.ForgotPassword)
+	authRoutes.POST("/reset-password", auth.ResetPassword)
+	authRoutes.POST("/verify-email", auth.VerifyEmail)
+	authRoutes.POST("/resend-verification", auth.ResendVerification)
+	authRoutes.GET(auth.OIDCLoginPath, auth.OIDCLogin)
+	authRoutes.GET(auth.OIDCCallbackPath, auth.OIDCCallback)
 
 	// Post routes
-	v1.GET(post.Path, post.GetAllPosts)
-	v1.GET(post.IdPath, post.GetPostByID)
+	v1.GET(post.Path, auth.OptionalAuthenticationMiddleWare, post.GetAllPosts)
+	v1.GET(post.IdPath, auth.OptionalAuthenticationMiddleWare, post.GetPostByID)
+	v1.GET(post.SlugPath, auth.OptionalAuthenticationMiddleWare, post.GetPostBySlug)
+	v1.GET(post.SearchPath, auth.OptionalAuthenticationMiddleWare, post.Search)
 	v1.GET(post.CategoryPath, post.GetAllCategories)
 	v1.GET(post.CategoryTreePath, post.GetCategoryTree)
 	v1.GET(post.CategoryIDPath, post.GetCategoryByID)
-	v1.GET(post.CommentByPostIDPath, post.GetCommentByPostID)
+	v1.GET(post.CategorySlugPath, post.GetCategoryBySlug)
+	v1.GET(post.TagPath, post.GetAllTags)
+	v1.GET(post.PostMediaPath, auth.OptionalAuthenticationMiddleWare, post.GetPostMedia)
+	v1.GET(post.CommentByPostIDPath, auth.OptionalAuthenticationMiddleWare, post.GetCommentByPostID)
+	v1.GET(post.CommentIdPath, auth.OptionalAuthenticationMiddleWare, post.GetCommentByID)
 }
 
 func setupProtectedRoutes(v1 *gin.RouterGroup) {
-	protected := v1.Group("/", auth.AuthenticationMiddleWare)
+	userLimit := ratelimit.Middleware("user", config.RateLimitUser)
+
+	// Routes left reachable to users who still have to enable two-factor authentication required for their role
+	enrollment := v1.Group("/", auth.AuthenticationMiddleWare, userLimit)
+	enrollment.GET("/me", auth.Me)
+	enrollment.POST("/logout", auth.DenyAPIKeyMiddleWare, auth.Logout)
+	enrollment.POST(auth.TwoFactorSetupPath, auth.DenyAPIKeyMiddleWare, auth.SetupTwoFactor)
+	enrollment.POST(auth.TwoFactorConfirmPath, auth.DenyAPIKeyMiddleWare, auth.ConfirmTwoFactor)
+
+	protected := v1.Group("/", auth.AuthenticationMiddleWare, userLimit, auth.TwoFactorPolicyMiddleWare)
 
 	// Auth routes
-	protected.POST("/logout", auth.Logout)
-	protected.GET("/me", auth.Me)
+	protected.PATCH("/me", auth.UpdateMe)
+	protected.GET(auth.IdentitiesPath, auth.GetMyIdentities)
+
+	// Credential management, which requires a login and not an API key
+	credentials := protected.Group("/", auth.DenyAPIKeyMiddleWare)
+	credentials.POST("/me/password", auth.ChangePassword)
+	credentials.GET(auth.SessionsPath, auth.GetMySessions)
+	credentials.POST(auth.SessionsRevokeOthersPath, auth.RevokeOtherSessions)
+	credentials.DELETE(auth.SessionIDPath, auth.RevokeMySession)
+	credentials.POST(auth.TwoFactorDisablePath, auth.DisableTwoFactor)
+	credentials.POST(auth.TwoFactorRecoveryCodesPath, auth.RegenerateRecoveryCodes)
+	credentials.POST(auth.APIKeysPath, auth.CreateMyAPIKey)
+	credentials.GET(auth.APIKeysPath, auth.GetMyAPIKeys)
+	credentials.DELETE(auth.APIKeyIDPath, auth.RevokeMyAPIKey)
 
 	// Routes only accessible to ADMIN
 	adminOnly := protected.Group("/", auth.AuthorizeRoles("ADMIN"))
@@ -51,6 +110,28 @@ func setupProtectedRoutes(v1 *gin.RouterGroup) {
 		adminOnly.POST(post.CategoryPath, post.CreateCategory)
 		adminOnly.PUT(post.CategoryIDPath, post.UpdateCategory)
 		adminOnly.DELETE(post.CategoryIDPath, post.DeleteCategory)
+
+		adminOnly.GET(user.Path, user.GetAllUsers)
+		adminOnly.GET(user.IdPath, user.GetUserByID)
+		adminOnly.PUT(user.RolePath, user.UpdateUserRole)
+		adminOnly.PUT(user.StatusPath, user.UpdateUserStatus)
+		adminOnly.POST(user.BanPath, user.BanUser)
+		adminOnly.DELETE(user.IdPath, user.DeleteUser)
+		adminOnly.POST(user.RestorePath, user.RestoreUser)
+		adminOnly.DELETE(user.SessionsPath, user.RevokeUserSessions)
+		adminOnly.GET(user.LoginsPath, user.GetUserLogins)
+		adminOnly.POST(user.APIKeysPath, auth.DenyAPIKeyMiddleWare, user.CreateUserAPIKey)
+		adminOnly.GET(user.APIKeysPath, user.GetUserAPIKeys)
+		adminOnly.DELETE(user.APIKeyIDPath, auth.DenyAPIKeyMiddleWare, user.RevokeUserAPIKey)
+
+		adminOnly.PUT(post.TagIDPath, post.RenameTag)
+		adminOnly.POST(post.TagMergePath, post.MergeTag)
+		adminOnly.DELETE(post.TagIDPath, post.DeleteTag)
+
+		adminOnly.GET(post.CommentAllPath, post.GetAllComments)
+		adminOnly.GET(post.CommentModerationPath, post.GetModerationQueue)
+		adminOnly.POST(post.CommentModerationApprovePath, post.ApproveComments)
+		adminOnly.POST(post.CommentModerationRejectPath, post.RejectComments)
 	}
 
 	// Routes accessible to ADMIN and AUTHOR
@@ -59,12 +140,22 @@ func setupProtectedRoutes(v1 *gin.RouterGroup) {
 		authorOrAdmin.POST(post.Path, post.CreatePost)
 		authorOrAdmin.PUT(post.IdPath, post.UpdatePost)
 		authorOrAdmin.DELETE(post.IdPath, post.DeletePost)
+		authorOrAdmin.GET(post.RevisionsPath, post.GetPostRevisions)
+		authorOrAdmin.GET(post.RevisionDiffPath, post.DiffPostRevisions)
+		authorOrAdmin.GET(post.RevisionNumberPath, post.GetPostRevision)
+		authorOrAdmin.POST(post.RevisionRestorePath, post.RestorePostRevision)
+		authorOrAdmin.POST(post.PostMediaPath, post.AttachPostMedia)
+		authorOrAdmin.DELETE(post.PostMediaIDPath, post.DetachPostMedia)
 
-		protected.GET(post.CommentAllPath, post.GetAllComments)
+		authorOrAdmin.POST(media.Path, media.UploadMedia)
+		authorOrAdmin.GET(media.Path, media.GetAllMedia)
+		authorOrAdmin.GET(media.IdPath, media.GetMediaByID)
+		authorOrAdmin.PATCH(media.IdPath, media.UpdateMedia)
+		authorOrAdmin.DELETE(media.IdPath, media.DeleteMedia)
 	}
 
 	// Public routes for all authenticated users
-	protected.POST(post.CommentPath, post.AddComment)
+	protected.POST(post.CommentPath, ratelimit.Middleware("comments", config.RateLimitComments), post.AddComment)
 	protected.PUT(post.CommentIdPath, post.UpdateComment)
 	protected.DELETE(post.CommentIdPath, post.DeleteComment)
 }
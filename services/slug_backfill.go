@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	postUtils "go-blog/utils/post"
+	"log"
+)
+
+// BackfillSlugs generates the missing slugs of posts and categories created before slugs existed.
+func BackfillSlugs() error {
+	var posts []postModel.Post
+	if err := config.Db.Unscoped().Where("slug = '' OR slug IS NULL").Find(&posts).Error; err != nil {
+		return fmt.Errorf("error retrieving posts without slug: %w", err)
+	}
+	for _, post := range posts {
+		if err := postUtils.SaveWithUniqueSlug(postModel.SlugEntityPost, post.Title, "post", post.ID, func(slug string) error {
+			return config.Db.Unscoped().Model(&post).Update("slug", slug).Error
+		}); err != nil {
+			return fmt.Errorf("error saving slug for post %d: %w", post.ID, err)
+		}
+	}
+
+	var categories []postModel.Category
+	if err := config.Db.Unscoped().Where("slug = '' OR slug IS NULL").Find(&categories).Error; err != nil {
+		return fmt.Errorf("error retrieving categories without slug: %w", err)
+	}
+	for _, category := range categories {
+		if err := postUtils.SaveWithUniqueSlug(postModel.SlugEntityCategory, category.Name, "category", category.ID, func(slug string) error {
+			return config.Db.Unscoped().Model(&category).Update("slug", slug).Error
+		}); err != nil {
+			return fmt.Errorf("error saving slug for category %d: %w", category.ID, err)
+		}
+	}
+
+	if len(posts)+len(categories) > 0 {
+		log.Printf("Slugs generated for %d post(s) and %d category(ies)", len(posts), len(categories))
+	}
+	return nil
+}
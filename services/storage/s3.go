@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Options configures an S3Storage
+type S3Options struct {
+	// Endpoint is the URL of the S3 API, e.g. https://s3.eu-west-3.amazonaws.com or http://localhost:9000 for MinIO
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// UsePathStyle sends requests to Endpoint/Bucket/key instead of Bucket.Endpoint/key
+	UsePathStyle bool
+	// PublicURL is the base URL of the files, the bucket URL when empty. The bucket, or the CDN in front of it,
+	// must allow public reads.
+	PublicURL string
+	Client    *http.Client
+}
+
+// S3Storage keeps files in a bucket of an S3-compatible object storage, signing requests with AWS Signature
+// Version 4
+type S3Storage struct {
+	options S3Options
+	client  *http.Client
+	baseURL *url.URL
+}
+
+func NewS3Storage(options S3Options) *S3Storage {
+	client := options.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(options.Endpoint, "/"))
+	if err != nil {
+		baseURL = &url.URL{}
+	}
+	if options.UsePathStyle {
+		baseURL.Path += "/" + options.Bucket
+	} else {
+		baseURL.Host = options.Bucket + "." + baseURL.Host
+	}
+
+	if options.PublicURL == "" {
+		options.PublicURL = baseURL.String()
+	}
+	options.PublicURL = strings.TrimSuffix(options.PublicURL, "/")
+
+	return &S3Storage{options: options, client: client, baseURL: baseURL}
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	response, err := s.do(ctx, http.MethodPut, key, data, map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return s.error("uploading", key, response)
+	}
+	return nil
+}
+
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	response, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, ErrNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, s.error("downloading", key, response)
+	}
+	return response.Body, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	response, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// Deleting a missing object succeeds with 204
+	if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusOK {
+		return s.error("deleting", key, response)
+	}
+	return nil
+}
+
+func (s *S3Storage) URL(key string) string {
+	return s.options.PublicURL + "/" + escapeKey(key)
+}
+
+func (s *S3Storage) do(ctx context.Context, method, key string, body []byte, headers map[string]string) (*http.Response, error) {
+	target := *s.baseURL
+	target.Path += "/" + key
+	target.RawPath = s.baseURL.EscapedPath() + "/" + escapeKey(key)
+
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = int64(len(body))
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	s.sign(request, body, time.Now().UTC())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending %s request for %s: %w", method, key, err)
+	}
+	return response, nil
+}
+
+// sign adds the Authorization header of AWS Signature Version 4, signing the host and every header set on the
+// request
+func (s *S3Storage) sign(request *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.options.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.options.SecretAccessKey), date)
+	key = hmacSHA256(key, s.options.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.options.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func (s *S3Storage) error(action, key string, response *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	return fmt.Errorf("error %s %s: %s: %s", action, key, response.Status, strings.TrimSpace(string(message)))
+}
+
+// escapeKey encodes every byte of the key but the unreserved characters and slashes, as S3 expects in
+// canonical requests
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"go-blog/services/config"
+	"io"
+	"log"
+)
+
+// ErrNotFound is returned when no file is stored under a key
+var ErrNotFound = errors.New("file not found")
+
+// Storage keeps uploaded files under keys such as 2025/01/5f1c...9a.jpg
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of the file stored under the key
+	URL(key string) string
+}
+
+var defaultStorage Storage = NewLocalStorage("uploads", config.MediaPath)
+
+// Init selects the storage from the STORAGE_DRIVER configuration
+func Init() {
+	switch config.StorageDriver {
+	case config.StorageDriverS3:
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			log.Fatal("S3_ENDPOINT and S3_BUCKET are required by the s3 storage driver")
+		}
+		defaultStorage = NewS3Storage(S3Options{
+			Endpoint:        config.S3Endpoint,
+			Region:          config.S3Region,
+			Bucket:          config.S3Bucket,
+			AccessKeyID:     config.S3AccessKeyID,
+			SecretAccessKey: config.S3SecretAccessKey,
+			UsePathStyle:    config.S3UsePathStyle,
+			PublicURL:       config.StoragePublicURL,
+		})
+	case config.StorageDriverLocal:
+		defaultStorage = NewLocalStorage(config.StorageLocalPath, localPublicURL())
+	default:
+		log.Printf("Warning: unknown storage driver %q, files will be stored in %s", config.StorageDriver, config.StorageLocalPath)
+		defaultStorage = NewLocalStorage(config.StorageLocalPath, localPublicURL())
+	}
+}
+
+// Default returns the storage used by the application
+func Default() Storage {
+	return defaultStorage
+}
+
+// SetDefault replaces the storage used by the application, e.g. with a LocalStorage in a temporary directory in tests
+func SetDefault(storage Storage) {
+	defaultStorage = storage
+}
+
+func localPublicURL() string {
+	if config.StoragePublicURL != "" {
+		return config.StoragePublicURL
+	}
+	return config.MediaPath
+}
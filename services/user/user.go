@@ -0,0 +1,491 @@
+package user
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	authDTO "go-blog/dto/auth"
+	userDTO "go-blog/dto/user"
+	authModel "go-blog/models/auth"
+	userModel "go-blog/models/user"
+	authService "go-blog/services/auth"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	requestUtil "go-blog/utils/post"
+	userUtil "go-blog/utils/user"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	Path         = "/users"
+	IdPath       = "/users/:id"
+	RolePath     = "/users/:id/role"
+	StatusPath   = "/users/:id/status"
+	BanPath      = "/users/:id/ban"
+	RestorePath  = "/users/:id/restore"
+	SessionsPath = "/users/:id/sessions"
+	LoginsPath   = "/users/:id/logins"
+	APIKeysPath  = "/users/:id/api-keys"
+	APIKeyIDPath = "/users/:id/api-keys/:keyId"
+	NotFound     = "User not found"
+	SelfModified = "You cannot change your own account from the admin API"
+)
+
+// GetAllUsers @Summary Get paginated list of users
+// @Description List and search users by email or name, optionally filtered by role and status
+// @Tags Users
+// @Produce json
+// @Param q query string false "Text to search in email, first name and last name"
+// @Param role query string false "Role to filter by" Enums(AUTHOR, CONTRIBUTOR, ADMIN, READER)
+// @Param status query string false "Status to filter by" Enums(ACTIVE, INACTIVE, BANNED, PENDING)
+// @Param include_deleted query bool false "Include soft-deleted users"
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Items per page (default is 10)"
+// @Success 200 {object} utils.PaginatedResponse[user.AdminUserResponse]
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users [get]
+func GetAllUsers(ctx *gin.Context) {
+	page, limit, offset := requestUtil.ParsePaginationParams(ctx)
+
+	query := config.Db.Model(&userModel.User{})
+	if ctx.Query("include_deleted") == "true" {
+		query = query.Unscoped()
+	}
+	if text := strings.TrimSpace(ctx.Query("q")); text != "" {
+		like := "%" + text + "%"
+		query = query.Where("email LIKE ? OR first_name LIKE ? OR last_name LIKE ?", like, like, like)
+	}
+	if role := ctx.Query("role"); role != "" {
+		query = query.Where("role = ?", strings.ToUpper(role))
+	}
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", strings.ToUpper(status))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error retrieving total count")
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	if totalPages > 0 && page > totalPages {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse("Page not found"))
+		return
+	}
+
+	var users []userModel.User
+	if err := query.Limit(limit).Offset(offset).Order("id ASC").Find(&users).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error retrieving users from the database")
+		return
+	}
+
+	response := make([]userDTO.AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		response = append(response, userDTO.ToAdminUserResponse(u))
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+}
+
+// GetUserByID @Summary Get user by ID
+// @Description Retrieve a user, including soft-deleted ones
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} user.AdminUserResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id} [get]
+func GetUserByID(ctx *gin.Context) {
+	var target userModel.User
+	if err := config.Db.Unscoped().First(&target, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.ToAdminUserResponse(target))
+}
+
+// UpdateUserRole @Summary Change the role of a user
+// @Description Change the role of a user. Demoted users are logged out of all their sessions.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body user.UpdateRoleRequest true "New role"
+// @Success 200 {object} user.AdminUserResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/role [put]
+func UpdateUserRole(ctx *gin.Context) {
+	var request userDTO.UpdateRoleRequest
+	if !requestUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	target, ok := findManagedUser(ctx)
+	if !ok {
+		return
+	}
+
+	demoted := userUtil.IsDemotion(target.Role, request.Role)
+	target.Role = request.Role
+	if err := config.Db.Model(&userModel.User{}).Where("id = ?", target.ID).Update("role", target.Role).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error updating user role")
+		return
+	}
+
+	if demoted && !revokeSessions(ctx, target.ID) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userDTO.ToAdminUserResponse(target))
+}
+
+// UpdateUserStatus @Summary Change the status of a user
+// @Description Change the status of a user. Users who are no longer active are logged out of all their sessions.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body user.UpdateStatusRequest true "New status"
+// @Success 200 {object} user.AdminUserResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/status [put]
+func UpdateUserStatus(ctx *gin.Context) {
+	var request userDTO.UpdateStatusRequest
+	if !requestUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	target, ok := findManagedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := applyStatus(&target, request.Status, request.Reason); err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error updating user status")
+		return
+	}
+
+	if target.Status != string(userModel.StatusActive) && !revokeSessions(ctx, target.ID) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userDTO.ToAdminUserResponse(target))
+}
+
+// BanUser @Summary Ban a user
+// @Description Ban a user with a reason and log them out of all their sessions
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body user.BanRequest true "Ban reason"
+// @Success 200 {object} user.AdminUserResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/ban [post]
+func BanUser(ctx *gin.Context) {
+	var request userDTO.BanRequest
+	if !requestUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	target, ok := findManagedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := applyStatus(&target, string(userModel.StatusBanned), request.Reason); err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error banning user")
+		return
+	}
+
+	if !revokeSessions(ctx, target.ID) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userDTO.ToAdminUserResponse(target))
+}
+
+// DeleteUser @Summary Soft-delete a user
+// @Description Soft-delete a user and log them out of all their sessions. The user can be restored later.
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id} [delete]
+func DeleteUser(ctx *gin.Context) {
+	target, ok := findManagedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := config.Db.Delete(&target).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error deleting user")
+		return
+	}
+
+	if !revokeSessions(ctx, target.ID) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// RestoreUser @Summary Restore a soft-deleted user
+// @Description Restore a user previously soft-deleted
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} user.AdminUserResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/restore [post]
+func RestoreUser(ctx *gin.Context) {
+	var target userModel.User
+	if err := config.Db.Unscoped().First(&target, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	if !target.DeletedAt.Valid {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse("User is not deleted"))
+		return
+	}
+
+	if err := config.Db.Unscoped().Model(&target).Update("deleted_at", nil).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error restoring user")
+		return
+	}
+	target.DeletedAt.Valid = false
+
+	ctx.JSON(http.StatusOK, userDTO.ToAdminUserResponse(target))
+}
+
+// RevokeUserSessions @Summary Log a user out of every session
+// @Description Revoke every session of a user, on all their devices
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/sessions [delete]
+func RevokeUserSessions(ctx *gin.Context) {
+	target, ok := findManagedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if !revokeSessions(ctx, target.ID) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User sessions revoked successfully"})
+}
+
+// GetUserLogins @Summary Get the login history of a user
+// @Description List the successful and failed login attempts on the account of a user, most recent first
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Items per page (default is 10)"
+// @Success 200 {object} utils.PaginatedResponse[user.LoginAttemptResponse]
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/logins [get]
+func GetUserLogins(ctx *gin.Context) {
+	var target userModel.User
+	if err := config.Db.Unscoped().First(&target, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	page, limit, offset := requestUtil.ParsePaginationParams(ctx)
+
+	// Failed attempts on an unknown password are not linked to the user, so the email is matched too
+	query := config.Db.Model(&authModel.LoginAttempt{}).Where("user_id = ? OR email = ?", target.ID, strings.ToLower(target.Email))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error retrieving total count")
+		return
+	}
+
+	var attempts []authModel.LoginAttempt
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&attempts).Error; err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error retrieving login attempts")
+		return
+	}
+
+	response := make([]userDTO.LoginAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		response = append(response, userDTO.ToLoginAttemptResponse(attempt))
+	}
+
+	ctx.JSON(http.StatusOK, utils.NewPaginatedResponse(response, page, limit, total))
+}
+
+// CreateUserAPIKey @Summary Create an API key for a user
+// @Description Create a named, scoped and expiring API key on behalf of a user, typically a service account. The key is only returned in this response.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body auth.CreateAPIKeyRequest true "Name, scopes and lifetime of the key"
+// @Success 201 {object} auth.CreatedAPIKeyResponse
+// @Failure 400 {object} utils.ValidationErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/api-keys [post]
+func CreateUserAPIKey(ctx *gin.Context) {
+	var request authDTO.CreateAPIKeyRequest
+	if !requestUtil.BindAndValidateJSON(ctx, &request) {
+		return
+	}
+
+	target, ok := findManagedUser(ctx)
+	if !ok {
+		return
+	}
+
+	var createdByID *uint
+	if currentUser, ok := authUtils.GetAuthenticatedUser(ctx); ok {
+		createdByID = &currentUser.ID
+	}
+
+	key, apiKey, err := authService.IssueAPIKey(target.ID, request, createdByID)
+	if errors.Is(err, authService.ErrTooManyAPIKeys) {
+		ctx.JSON(http.StatusConflict, utils.NewErrorResponse(authService.TooManyAPIKeys))
+		return
+	}
+	if err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error creating API key")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, authDTO.NewCreatedAPIKeyResponse(apiKey, key))
+}
+
+// GetUserAPIKeys @Summary Get the API keys of a user
+// @Description List the API keys of a user, including the expired and revoked ones
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} auth.APIKeyResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/api-keys [get]
+func GetUserAPIKeys(ctx *gin.Context) {
+	var target userModel.User
+	if err := config.Db.Unscoped().First(&target, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	apiKeys, err := authService.ListAPIKeys(target.ID)
+	if err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error retrieving API keys")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authDTO.NewAPIKeyResponses(apiKeys))
+}
+
+// RevokeUserAPIKey @Summary Revoke an API key of a user
+// @Description Revoke an API key of a user, effective immediately
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param keyId path int true "API key ID"
+// @Success 200 {object} auth.APIKeyRevokeResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Security BearerAuth
+// @Router /v1/users/{id}/api-keys/{keyId} [delete]
+func RevokeUserAPIKey(ctx *gin.Context) {
+	var target userModel.User
+	if err := config.Db.Unscoped().First(&target, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return
+	}
+
+	err := authService.RevokeAPIKey(target.ID, ctx.Param("keyId"))
+	if errors.Is(err, authService.ErrAPIKeyNotFound) {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(authService.APIKeyNotFound))
+		return
+	}
+	if err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error revoking API key")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authDTO.APIKeyRevokeResponse{Message: "API key revoked successfully"})
+}
+
+// findManagedUser loads the user targeted by the request, refusing admins acting on their own account
+func findManagedUser(ctx *gin.Context) (userModel.User, bool) {
+	var target userModel.User
+	if err := config.Db.First(&target, ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, utils.NewErrorResponse(NotFound))
+		return target, false
+	}
+
+	if currentUser, ok := authUtils.GetAuthenticatedUser(ctx); ok && currentUser.ID == target.ID {
+		ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse(SelfModified))
+		return target, false
+	}
+
+	return target, true
+}
+
+func applyStatus(target *userModel.User, status, reason string) error {
+	target.Status = status
+	if status == string(userModel.StatusBanned) {
+		now := time.Now()
+		target.BannedAt = &now
+		target.BanReason = reason
+	} else {
+		target.BannedAt = nil
+		target.BanReason = ""
+	}
+	return config.Db.Model(&userModel.User{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+		"status":     target.Status,
+		"banned_at":  target.BannedAt,
+		"ban_reason": target.BanReason,
+	}).Error
+}
+
+func revokeSessions(ctx *gin.Context, userID uint) bool {
+	if err := authService.RevokeUserSessions(userID); err != nil {
+		requestUtil.HandleDatabaseError(ctx, "Error revoking user sessions")
+		return false
+	}
+	return true
+}
@@ -1,7 +1,12 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"github.com/gin-gonic/gin"
 	"go-blog/models/user"
 	"go-blog/services/config"
 	"golang.org/x/crypto/bcrypt"
@@ -16,6 +21,26 @@ func HashPassword(password string) (string, error) {
 	return string(hashed), nil
 }
 
+// VerifyPassword reports whether the password matches the bcrypt hash
+func VerifyPassword(hashedPassword, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
+}
+
+// GenerateRandomToken returns a URL-safe random token built from n random bytes
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 hash of a token, used to store tokens without keeping them in clear
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func ValidateCredentials(email, password string) (*user.User, error) {
 	var userModel user.User
 	if err := config.Db.Where("email = ?", email).First(&userModel).Error; err != nil {
@@ -47,3 +72,53 @@ func ExtractBearerToken(authHeader string) (string, bool) {
 	}
 	return token, true
 }
+
+// GetAuthenticatedUser returns the user stored in the context by the authentication middleware
+func GetAuthenticatedUser(ctx *gin.Context) (user.User, bool) {
+	userAny, exists := ctx.Get("user")
+	if !exists {
+		return user.User{}, false
+	}
+	userModel, ok := userAny.(user.User)
+	return userModel, ok
+}
+
+// SessionIDKey is the context key under which the authentication middleware stores the ID of the current session
+const SessionIDKey = "session_id"
+
+// GetSessionID returns the ID of the session the access token of the request was issued for
+func GetSessionID(ctx *gin.Context) (uint, bool) {
+	sessionID, ok := ctx.Get(SessionIDKey)
+	if !ok {
+		return 0, false
+	}
+	id, ok := sessionID.(uint)
+	return id, ok
+}
+
+// GetViewer returns the authenticated user when there is one, or nil for anonymous requests
+func GetViewer(ctx *gin.Context) *user.User {
+	userModel, ok := GetAuthenticatedUser(ctx)
+	if !ok {
+		return nil
+	}
+	return &userModel
+}
+
+// IsAdmin reports whether the given user has the ADMIN role
+func IsAdmin(userModel user.User) bool {
+	return strings.EqualFold(userModel.Role, string(user.RoleAdmin))
+}
+
+// APIKeyIDKey is the context key under which the authentication middleware stores the ID of the API key of the request
+const APIKeyIDKey = "api_key_id"
+
+// GetAPIKeyID returns the ID of the API key the request was authenticated with, if any
+func GetAPIKeyID(ctx *gin.Context) (uint, bool) {
+	apiKeyID, ok := ctx.Get(APIKeyIDKey)
+	if !ok {
+		return 0, false
+	}
+	id, ok := apiKeyID.(uint)
+	return id, ok
+}
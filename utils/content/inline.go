@@ -0,0 +1,437 @@
+package content
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	autolinkPattern = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+	emailPattern    = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+)
+
+// Links with a label longer than maxLabelLength, or a destination and title longer than maxDestinationLength, are
+// not links, so that unclosed brackets and parentheses are not searched to the end of the text
+const (
+	maxLabelLength       = 1000
+	maxDestinationLength = 4000
+)
+
+// inlineNode is either rendered HTML or a run of emphasis delimiters waiting to be matched
+type inlineNode struct {
+	html string
+
+	delimiter byte
+	count     int
+	original  int
+	canOpen   bool
+	canClose  bool
+	// Tags added after the remaining delimiters of an opener, or before those of a closer
+	openTags  []string
+	closeTags []string
+}
+
+func (n *inlineNode) render() string {
+	if n.delimiter == 0 {
+		return n.html
+	}
+	return strings.Join(n.closeTags, "") + strings.Repeat(string(n.delimiter), n.count) + strings.Join(n.openTags, "")
+}
+
+// renderInline renders the inlines of a paragraph or a heading
+func renderInline(text string) string {
+	nodes := parseInline(text, true)
+	processEmphasis(nodes)
+
+	var b strings.Builder
+	for _, node := range nodes {
+		b.WriteString(node.render())
+	}
+	return b.String()
+}
+
+func parseInline(text string, allowLinks bool) []*inlineNode {
+	var nodes []*inlineNode
+	var pending strings.Builder
+	// Lengths of the backtick runs without a closing run after them, which later runs can not have either
+	unclosed := map[int]bool{}
+	flush := func() {
+		if pending.Len() > 0 {
+			nodes = append(nodes, &inlineNode{html: pending.String()})
+			pending.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			flush()
+			nodes = append(nodes, &inlineNode{html: "<br />\n"})
+			i += 2
+		case c == '\\' && i+1 < len(text) && isASCIIPunctuation(text[i+1]):
+			pending.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+		case c == '\n':
+			// Two trailing spaces make a hard line break
+			trimmed := strings.TrimRight(pending.String(), " ")
+			hard := pending.Len()-len(trimmed) >= 2
+			pending.Reset()
+			pending.WriteString(trimmed)
+			flush()
+			if hard {
+				nodes = append(nodes, &inlineNode{html: "<br />\n"})
+			} else {
+				nodes = append(nodes, &inlineNode{html: "\n"})
+			}
+			i++
+		case c == '`':
+			run := countRun(text, i, '`')
+			end := -1
+			if !unclosed[run] {
+				end = findCodeSpanEnd(text, i+run, run)
+				unclosed[run] = end < 0
+			}
+			if end >= 0 {
+				flush()
+				code := strings.ReplaceAll(text[i+run:end], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				nodes = append(nodes, &inlineNode{html: "<code>" + html.EscapeString(code) + "</code>"})
+				i = end + run
+			} else {
+				pending.WriteString(text[i : i+run])
+				i += run
+			}
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if rendered, end, ok := parseLink(text, i+1, true); ok {
+				flush()
+				nodes = append(nodes, &inlineNode{html: rendered})
+				i = end
+			} else {
+				pending.WriteString("!")
+				i++
+			}
+		case c == '[' && allowLinks:
+			if rendered, end, ok := parseLink(text, i, false); ok {
+				flush()
+				nodes = append(nodes, &inlineNode{html: rendered})
+				i = end
+			} else {
+				pending.WriteString("[")
+				i++
+			}
+		case c == '<':
+			if m := autolinkPattern.FindStringSubmatch(text[i:]); m != nil && allowLinks {
+				flush()
+				nodes = append(nodes, &inlineNode{html: `<a href="` + escapeURL(m[1]) + `">` + html.EscapeString(m[1]) + "</a>"})
+				i += len(m[0])
+			} else if m := emailPattern.FindStringSubmatch(text[i:]); m != nil && allowLinks {
+				flush()
+				nodes = append(nodes, &inlineNode{html: `<a href="mailto:` + escapeURL(m[1]) + `">` + html.EscapeString(m[1]) + "</a>"})
+				i += len(m[0])
+			} else {
+				pending.WriteString("&lt;")
+				i++
+			}
+		case c == '*' || c == '_' || c == '~':
+			flush()
+			run := countRun(text, i, c)
+			before, _ := utf8.DecodeLastRuneInString(text[:i])
+			after, _ := utf8.DecodeRuneInString(text[i+run:])
+			if i == 0 {
+				before = ' '
+			}
+			if i+run == len(text) {
+				after = ' '
+			}
+			leftFlanking := !unicode.IsSpace(after) && (!isPunctuation(after) || unicode.IsSpace(before) || isPunctuation(before))
+			rightFlanking := !unicode.IsSpace(before) && (!isPunctuation(before) || unicode.IsSpace(after) || isPunctuation(after))
+			node := &inlineNode{delimiter: c, count: run, original: run, canOpen: leftFlanking, canClose: rightFlanking}
+			if c == '_' {
+				// Underscores inside words are not emphasis
+				node.canOpen = leftFlanking && (!rightFlanking || isPunctuation(before))
+				node.canClose = rightFlanking && (!leftFlanking || isPunctuation(after))
+			}
+			nodes = append(nodes, node)
+			i += run
+		default:
+			r, size := utf8.DecodeRuneInString(text[i:])
+			pending.WriteString(html.EscapeString(string(r)))
+			i += size
+		}
+	}
+	flush()
+	return nodes
+}
+
+// processEmphasis matches the delimiter runs following the CommonMark rules: each closer is matched with the
+// closest opener of the same kind before it
+func processEmphasis(nodes []*inlineNode) {
+	// Once no opener is found for a kind of closer, the search for the next ones stops at that closer
+	type closerKind struct {
+		delimiter byte
+		canOpen   bool
+		double    bool
+		mod3      int
+	}
+	bottom := map[closerKind]int{}
+	// Indexes of the delimiter runs that can still open emphasis
+	var openers []int
+
+	for c, closer := range nodes {
+		if closer.delimiter == 0 {
+			continue
+		}
+		for closer.canClose && closer.count > 0 {
+			kind := closerKind{closer.delimiter, closer.canOpen, closer.count >= 2, closer.original % 3}
+			floor, ok := bottom[kind]
+			if !ok {
+				floor = -1
+			}
+			s := len(openers) - 1
+			for ; s >= 0 && openers[s] > floor; s-- {
+				opener := nodes[openers[s]]
+				if opener.delimiter != closer.delimiter {
+					continue
+				}
+				if closer.delimiter == '~' && (opener.count < 2 || closer.count < 2) {
+					continue
+				}
+				// The rule of three keeps "*foo**bar*" from matching the inner delimiters
+				if (opener.canClose || closer.canOpen) && (opener.original+closer.original)%3 == 0 &&
+					!(opener.original%3 == 0 && closer.original%3 == 0) {
+					continue
+				}
+				break
+			}
+			if s < 0 || openers[s] <= floor {
+				bottom[kind] = c - 1
+				break
+			}
+
+			opener := nodes[openers[s]]
+			used, tag := 1, "em"
+			switch {
+			case closer.delimiter == '~':
+				used, tag = 2, "del"
+			case opener.count >= 2 && closer.count >= 2:
+				used, tag = 2, "strong"
+			}
+			opener.count -= used
+			closer.count -= used
+			opener.openTags = append([]string{"<" + tag + ">"}, opener.openTags...)
+			closer.closeTags = append(closer.closeTags, "</"+tag+">")
+
+			// Delimiters between the opener and the closer can no longer be matched
+			openers = openers[:s+1]
+			if opener.count == 0 {
+				openers = openers[:s]
+			}
+		}
+		if closer.canOpen && closer.count > 0 {
+			openers = append(openers, c)
+		}
+	}
+}
+
+// parseLink parses a link or an image starting at the opening bracket, and returns the rendered HTML and the
+// position after the link
+func parseLink(text string, start int, image bool) (string, int, bool) {
+	closing := findClosingBracket(text, start)
+	if closing < 0 || closing+1 >= len(text) || text[closing+1] != '(' {
+		return "", 0, false
+	}
+	destination, title, end, ok := parseLinkDestination(text, closing+2)
+	if !ok {
+		return "", 0, false
+	}
+	label := text[start+1 : closing]
+
+	var b strings.Builder
+	if image {
+		b.WriteString(`<img src="` + escapeURL(destination) + `" alt="` + html.EscapeString(plainText(label)) + `"`)
+		if title != "" {
+			b.WriteString(` title="` + html.EscapeString(title) + `"`)
+		}
+		b.WriteString(" />")
+		return b.String(), end, true
+	}
+
+	nodes := parseInline(label, false)
+	processEmphasis(nodes)
+	b.WriteString(`<a href="` + escapeURL(destination) + `"`)
+	if title != "" {
+		b.WriteString(` title="` + html.EscapeString(title) + `"`)
+	}
+	b.WriteString(">")
+	for _, node := range nodes {
+		b.WriteString(node.render())
+	}
+	b.WriteString("</a>")
+	return b.String(), end, true
+}
+
+func findClosingBracket(text string, start int) int {
+	text = text[:min(len(text), start+maxLabelLength+2)]
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '`':
+			run := countRun(text, i, '`')
+			if end := findCodeSpanEnd(text, i+run, run); end >= 0 {
+				i = end + run - 1
+			} else {
+				i += run - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseLinkDestination parses `destination "title")` and returns the position after the closing parenthesis
+func parseLinkDestination(text string, start int) (string, string, int, bool) {
+	text = text[:min(len(text), start+maxDestinationLength)]
+	i := skipSpaces(text, start)
+	var destination string
+
+	if i < len(text) && text[i] == '<' {
+		end := strings.IndexAny(text[i+1:], ">\n")
+		if end < 0 || text[i+1+end] != '>' {
+			return "", "", 0, false
+		}
+		destination = text[i+1 : i+1+end]
+		i += end + 2
+	} else {
+		depth := 0
+		begin := i
+		for ; i < len(text); i++ {
+			c := text[i]
+			if c == '\\' && i+1 < len(text) && isASCIIPunctuation(text[i+1]) {
+				i++
+				continue
+			}
+			if c == ' ' || c == '\n' || c < 0x20 {
+				break
+			}
+			if c == '(' {
+				depth++
+			}
+			if c == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		destination = text[begin:i]
+	}
+
+	title := ""
+	afterDestination := i
+	i = skipSpaces(text, i)
+	if i < len(text) && i > afterDestination && (text[i] == '"' || text[i] == '\'' || text[i] == '(') {
+		closing := text[i]
+		if closing == '(' {
+			closing = ')'
+		}
+		end := strings.IndexByte(text[i+1:], closing)
+		if end < 0 {
+			return "", "", 0, false
+		}
+		title = unescapeBackslashes(text[i+1 : i+1+end])
+		i = skipSpaces(text, i+end+2)
+	}
+
+	if i >= len(text) || text[i] != ')' {
+		return "", "", 0, false
+	}
+	return unescapeBackslashes(destination), title, i + 1, true
+}
+
+// plainText returns the text of a label without its Markdown syntax, for the alternative text of images
+func plainText(label string) string {
+	var b strings.Builder
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if c == '\\' && i+1 < len(label) && isASCIIPunctuation(label[i+1]) {
+			b.WriteByte(label[i+1])
+			i++
+			continue
+		}
+		if strings.IndexByte("*_~`[]!", c) >= 0 {
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// findCodeSpanEnd returns the start of the backtick run closing a code span, or -1
+func findCodeSpanEnd(text string, start, length int) int {
+	for i := start; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		run := countRun(text, i, '`')
+		if run == length {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+func countRun(text string, start int, c byte) int {
+	n := 0
+	for start+n < len(text) && text[start+n] == c {
+		n++
+	}
+	return n
+}
+
+func skipSpaces(text string, i int) int {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func unescapeBackslashes(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && isASCIIPunctuation(text[i+1]) {
+			i++
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// escapeURL percent-encodes the spaces and quotes of a link destination and escapes it for an attribute
+func escapeURL(url string) string {
+	url = strings.NewReplacer(" ", "%20", `"`, "%22", "`", "%60").Replace(url)
+	return html.EscapeString(url)
+}
+
+func isASCIIPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
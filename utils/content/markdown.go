@@ -0,0 +1,297 @@
+// Package content renders the Markdown and HTML written by authors and commenters into safe HTML.
+//
+// The Markdown renderer supports the CommonMark blocks (paragraphs, ATX and setext headings, thematic breaks,
+// fenced and indented code, block quotes, bullet and ordered lists) and inlines (emphasis, code spans, links,
+// images, autolinks, hard line breaks and backslash escapes), plus ~~strikethrough~~. Raw HTML is not interpreted
+// in Markdown: it is shown as text. Reference links and tables are not supported.
+package content
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	atxHeadingPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	thematicBreakPattern = regexp.MustCompile(`^ {0,3}((?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern         = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*)$")
+	setextPattern        = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	listMarkerPattern    = regexp.MustCompile(`^( {0,3})([-+*]|\d{1,9}[.)])([ \t]+|$)`)
+)
+
+// Block quotes and lists nested deeper than maxNesting are rendered as paragraphs, to bound the recursion
+const maxNesting = 32
+
+// Markdown renders Markdown source into HTML. The result should still be sanitized, as link destinations are
+// not checked.
+func Markdown(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(source, "\n"), false, 0)
+	return b.String()
+}
+
+// renderBlocks renders a sequence of lines as blocks. In tight lists, paragraphs are not wrapped in <p>.
+func renderBlocks(b *strings.Builder, lines []string, tight bool, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case fencePattern.MatchString(line):
+			i = renderFencedCode(b, lines, i)
+		case indentation(line) >= 4:
+			i = renderIndentedCode(b, lines, i)
+		case atxHeadingPattern.MatchString(line):
+			m := atxHeadingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderInline(strings.TrimSpace(m[2])) + "</h" + level + ">\n")
+			i++
+		case thematicBreakPattern.MatchString(line):
+			b.WriteString("<hr />\n")
+			i++
+		case isBlockquote(line) && depth < maxNesting:
+			i = renderBlockquote(b, lines, i, depth)
+		case listMarkerPattern.MatchString(line) && depth < maxNesting:
+			i = renderList(b, lines, i, depth)
+		default:
+			i = renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+func renderFencedCode(b *strings.Builder, lines []string, start int) int {
+	m := fencePattern.FindStringSubmatch(lines[start])
+	indent, fence, info := len(m[1]), m[2], strings.TrimSpace(m[3])
+
+	b.WriteString("<pre><code")
+	if language := strings.Fields(info); len(language) > 0 {
+		b.WriteString(` class="language-` + html.EscapeString(language[0]) + `"`)
+	}
+	b.WriteString(">")
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if indentation(line) < 4 && strings.HasPrefix(trimmed, fence[:1]) &&
+			len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		// The indentation of the opening fence is removed from the content
+		removed := 0
+		for removed < indent && removed < len(line) && line[removed] == ' ' {
+			removed++
+		}
+		b.WriteString(html.EscapeString(line[removed:]) + "\n")
+	}
+
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func renderIndentedCode(b *strings.Builder, lines []string, start int) int {
+	end := start
+	var code []string
+	for i := start; i < len(lines); i++ {
+		if isBlank(lines[i]) {
+			code = append(code, "")
+			continue
+		}
+		if indentation(lines[i]) < 4 {
+			break
+		}
+		code = append(code, lines[i][4:])
+		end = i + 1
+	}
+	// Trailing blank lines are not part of the code
+	code = code[:end-start]
+
+	b.WriteString("<pre><code>")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return end
+}
+
+func renderBlockquote(b *strings.Builder, lines []string, start, depth int) int {
+	var inner []string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlockquote(line) {
+			line = strings.TrimLeft(line, " ")[1:]
+			if strings.HasPrefix(line, " ") {
+				line = line[1:]
+			}
+			inner = append(inner, line)
+			continue
+		}
+		// A paragraph in the quote goes on until a blank line or another block
+		if isBlank(line) || startsBlock(line) || len(inner) == 0 || isBlank(inner[len(inner)-1]) {
+			break
+		}
+		inner = append(inner, line)
+	}
+
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner, false, depth+1)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+type listItem struct {
+	lines []string
+}
+
+func renderList(b *strings.Builder, lines []string, start, depth int) int {
+	first := listMarkerPattern.FindStringSubmatch(lines[start])
+	ordered := !strings.ContainsAny(first[2], "-+*")
+	delimiter := first[2][len(first[2])-1:]
+
+	var items []listItem
+	loose := false
+	contentIndent := 0
+	blankBefore := false
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := listMarkerPattern.FindStringSubmatch(line); m != nil && (len(items) == 0 || indentation(line) < contentIndent &&
+			strings.HasSuffix(m[2], delimiter) && ordered == !strings.ContainsAny(m[2], "-+*") && !thematicBreakPattern.MatchString(line)) {
+			// A new item of the same list
+			if len(items) > 0 && blankBefore {
+				loose = true
+			}
+			spacing := len(m[3])
+			if spacing > 4 || strings.TrimSpace(line[len(m[0]):]) == "" {
+				spacing = 1
+			}
+			contentIndent = len(m[1]) + len(m[2]) + spacing
+			items = append(items, listItem{lines: []string{line[min(contentIndent, len(line)):]}})
+			blankBefore = false
+			continue
+		}
+
+		if isBlank(line) {
+			items[len(items)-1].lines = append(items[len(items)-1].lines, "")
+			blankBefore = true
+			continue
+		}
+
+		if indentation(line) >= contentIndent {
+			if blankBefore && !isBlank(items[len(items)-1].lines[0]) {
+				loose = true
+			}
+			items[len(items)-1].lines = append(items[len(items)-1].lines, line[contentIndent:])
+			blankBefore = false
+			continue
+		}
+
+		// A lazy continuation line of the paragraph of the item
+		if !blankBefore && !startsBlock(line) {
+			items[len(items)-1].lines = append(items[len(items)-1].lines, line)
+			continue
+		}
+		break
+	}
+
+	// Blank lines at the end of the list belong after it
+	for len(items) > 0 {
+		last := &items[len(items)-1]
+		if len(last.lines) < 2 || !isBlank(last.lines[len(last.lines)-1]) {
+			break
+		}
+		last.lines = last.lines[:len(last.lines)-1]
+		i--
+	}
+
+	if ordered {
+		number, _ := strconv.Atoi(first[2][:len(first[2])-1])
+		if number != 1 {
+			b.WriteString(`<ol start="` + strconv.Itoa(number) + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	for _, item := range items {
+		b.WriteString("<li>")
+		var inner strings.Builder
+		renderBlocks(&inner, item.lines, !loose, depth+1)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+
+	if ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+	return i
+}
+
+func renderParagraph(b *strings.Builder, lines []string, start int, tight bool) int {
+	text := []string{strings.TrimLeft(lines[start], " ")}
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := setextPattern.FindStringSubmatch(line); m != nil && indentation(line) < 4 {
+			level := "1"
+			if m[1][0] == '-' {
+				level = "2"
+			}
+			b.WriteString("<h" + level + ">" + renderInline(strings.TrimSpace(strings.Join(text, "\n"))) + "</h" + level + ">\n")
+			return i + 1
+		}
+		if isBlank(line) || startsBlock(line) {
+			break
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+
+	inline := renderInline(strings.TrimRight(strings.Join(text, "\n"), " "))
+	if tight {
+		b.WriteString(inline + "\n")
+	} else {
+		b.WriteString("<p>" + inline + "</p>\n")
+	}
+	return i
+}
+
+// startsBlock reports whether the line interrupts a paragraph
+func startsBlock(line string) bool {
+	if indentation(line) >= 4 {
+		return false
+	}
+	if atxHeadingPattern.MatchString(line) || thematicBreakPattern.MatchString(line) ||
+		fencePattern.MatchString(line) || isBlockquote(line) {
+		return true
+	}
+	// Only bullet items and ordered items starting at 1 interrupt a paragraph, and never empty ones
+	if m := listMarkerPattern.FindStringSubmatch(line); m != nil && strings.TrimSpace(line[len(m[0]):]) != "" {
+		return strings.ContainsAny(m[2], "-+*") || m[2][:len(m[2])-1] == "1"
+	}
+	return false
+}
+
+func isBlockquote(line string) bool {
+	return indentation(line) < 4 && strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
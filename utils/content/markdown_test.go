@@ -0,0 +1,71 @@
+package content
+
+import "testing"
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"heading", "# Title #", "<h1>Title</h1>\n"},
+		{"setext heading", "Title\n===", "<h1>Title</h1>\n"},
+		{"emphasis", "*a* **b** ~~c~~", "<p><em>a</em> <strong>b</strong> <del>c</del></p>\n"},
+		{"unclosed emphasis", "**bold", "<p>**bold</p>\n"},
+		{"code span", "`<b>`", "<p><code>&lt;b&gt;</code></p>\n"},
+		{"fenced code", "```go\nx := 1 < 2\n```", "<pre><code class=\"language-go\">x := 1 &lt; 2\n</code></pre>\n"},
+		{"raw html", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"raw html in quote", "> <svg onload=alert(1)>", "<blockquote>\n<p>&lt;svg onload=alert(1)&gt;</p>\n</blockquote>\n"},
+		{"link", "[x](https://example.com)", "<p><a href=\"https://example.com\">x</a></p>\n"},
+		{"link title", `[x](https://example.com "t")`, "<p><a href=\"https://example.com\" title=\"t\">x</a></p>\n"},
+		{"link query", "[x](/posts/1?a=1&b=2)", "<p><a href=\"/posts/1?a=1&amp;b=2\">x</a></p>\n"},
+		{"html in link text", "[<img src=x onerror=alert(1)>](https://example.com)",
+			"<p><a href=\"https://example.com\">&lt;img src=x onerror=alert(1)&gt;</a></p>\n"},
+		{"quote in destination", `[x](https://example.com/"onmouseover="alert(1))`,
+			"<p><a href=\"https://example.com/%22onmouseover=%22alert(1)\">x</a></p>\n"},
+		{"quote in autolink", `<http://example.com/"onclick="x>`,
+			"<p><a href=\"http://example.com/%22onclick=%22x\">http://example.com/&#34;onclick=&#34;x</a></p>\n"},
+		{"unclosed link", "[x](https://example.com", "<p>[x](https://example.com</p>\n"},
+		{"image alt", `![a "b"](https://example.com/a.png)`, "<p><img src=\"https://example.com/a.png\" alt=\"a &#34;b&#34;\" /></p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Markdown(tt.input); got != tt.want {
+				t.Errorf("Markdown(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMarkdownHostileDestinations renders and sanitizes Markdown like the posts are, as Markdown does not check
+// the link destinations itself
+func TestMarkdownHostileDestinations(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"javascript link", "[x](javascript:alert(1))", "<p><a>x</a></p>\n"},
+		{"javascript link in brackets", "[x](<javascript:alert(1)>)", "<p><a>x</a></p>\n"},
+		{"uppercase scheme", "[x](JavaScript:alert(1))", "<p><a>x</a></p>\n"},
+		{"escaped tab in scheme", `[x](java\tscript:alert(1))`, "<p><a>x</a></p>\n"},
+		{"entity in scheme", "[x](java&#x73;cript:alert(1))", "<p><a href=\"java&amp;#x73;cript:alert(1)\">x</a></p>\n"},
+		{"vbscript link", "[x](vbscript:msgbox(1))", "<p><a>x</a></p>\n"},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p><a>x</a></p>\n"},
+		{"javascript autolink", "<javascript:alert(1)>", "<p><a>javascript:alert(1)</a></p>\n"},
+		{"javascript image", "![x](javascript:alert(1))", "<p></p>\n"},
+		{"data image", "![x](data:text/html,hi)", "<p></p>\n"},
+		{"mailto image", "![x](mailto:alice@example.com)", "<p></p>\n"},
+		{"mailto link", "[x](mailto:alice@example.com)", "<p><a href=\"mailto:alice@example.com\">x</a></p>\n"},
+		{"attribute injection", `[x](" onmouseover="alert(1))`, "<p>[x](&#34; onmouseover=&#34;alert(1))</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PostPolicy.Sanitize(Markdown(tt.input)); got != tt.want {
+				t.Errorf("Sanitize(Markdown(%q)) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
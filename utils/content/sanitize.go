@@ -0,0 +1,208 @@
+package content
+
+import (
+	"golang.org/x/net/html"
+	"regexp"
+	"strings"
+)
+
+// Policy is an allowlist of the elements and attributes kept by Sanitize. Everything else is removed: the
+// content of unknown elements is kept as text, except for the elements in droppedElements.
+type Policy struct {
+	elements map[string][]string
+	// linkRel is set as the rel attribute of every link when not empty
+	linkRel string
+}
+
+var (
+	// PostPolicy allows the formatting of posts, including headings, images and tables
+	PostPolicy = &Policy{
+		elements: map[string][]string{
+			"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+			"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+			"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "del": nil, "ins": nil,
+			"sub": nil, "sup": nil, "mark": nil, "small": nil, "kbd": nil, "abbr": {"title"},
+			"code": {"class"}, "pre": nil, "blockquote": {"cite"}, "q": {"cite"},
+			"a":   {"href", "title"},
+			"img": {"src", "alt", "title", "width", "height"},
+			"ul":  nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+			"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+			"th": {"colspan", "rowspan", "align"}, "td": {"colspan", "rowspan", "align"},
+			"figure": nil, "figcaption": nil,
+		},
+	}
+
+	// CommentPolicy allows basic formatting in comments. Links are marked as user generated and not followed
+	// by search engines.
+	CommentPolicy = &Policy{
+		elements: map[string][]string{
+			"p": nil, "br": nil,
+			"strong": nil, "b": nil, "em": nil, "i": nil, "s": nil, "del": nil,
+			"code": {"class"}, "pre": nil, "blockquote": nil,
+			"a":  {"href", "title"},
+			"ul": nil, "ol": {"start"}, "li": nil,
+		},
+		linkRel: "nofollow ugc",
+	}
+
+	// droppedElements are removed with their content
+	droppedElements = map[string]bool{
+		"script": true, "style": true, "iframe": true, "frame": true, "frameset": true, "object": true,
+		"embed": true, "applet": true, "noscript": true, "noembed": true, "template": true, "textarea": true,
+		"select": true, "svg": true, "math": true, "title": true, "head": true, "xmp": true, "plaintext": true,
+	}
+
+	voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+	numberPattern   = regexp.MustCompile(`^[0-9]{1,5}$`)
+	languagePattern = regexp.MustCompile(`^language-[A-Za-z0-9_+#-]{1,30}$`)
+	schemePattern   = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*):`)
+)
+
+// Sanitize returns the HTML with only the elements and attributes allowed by the policy, with every element
+// closed
+func (p *Policy) Sanitize(input string) string {
+	var b strings.Builder
+	var open []string
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+	// Name of the dropped element being skipped, and how many of them are nested
+	skipping, depth := "", 0
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+
+		if skipping != "" {
+			switch {
+			case tokenType == html.StartTagToken && token.Data == skipping:
+				depth++
+			case tokenType == html.EndTagToken && token.Data == skipping:
+				depth--
+				if depth == 0 {
+					skipping = ""
+				}
+			}
+			continue
+		}
+
+		switch tokenType {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[token.Data] {
+				if tokenType == html.StartTagToken && !voidElements[token.Data] {
+					skipping, depth = token.Data, 1
+				}
+				continue
+			}
+			allowed, ok := p.elements[token.Data]
+			if !ok {
+				continue
+			}
+			attributes, ok := p.attributes(token, allowed)
+			if !ok {
+				continue
+			}
+			if voidElements[token.Data] {
+				b.WriteString("<" + token.Data + attributes + " />")
+				continue
+			}
+			b.WriteString("<" + token.Data + attributes + ">")
+			open = append(open, token.Data)
+		case html.EndTagToken:
+			// Elements left open inside the closed one are closed with it, unmatched end tags are ignored
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// attributes renders the allowed and valid attributes of a tag. Links and images without a safe URL are removed.
+func (p *Policy) attributes(token html.Token, allowed []string) (string, bool) {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, attribute := range token.Attr {
+		if attribute.Namespace != "" || seen[attribute.Key] || !contains(allowed, attribute.Key) {
+			continue
+		}
+		value := strings.TrimSpace(attribute.Val)
+		switch attribute.Key {
+		case "href", "cite":
+			if !safeURL(value, "http", "https", "mailto") {
+				continue
+			}
+		case "src":
+			if !safeURL(value, "http", "https") {
+				continue
+			}
+		case "width", "height", "start", "colspan", "rowspan":
+			if !numberPattern.MatchString(value) {
+				continue
+			}
+		case "class":
+			if !languagePattern.MatchString(value) {
+				continue
+			}
+		case "align":
+			if value != "left" && value != "center" && value != "right" {
+				continue
+			}
+		}
+		seen[attribute.Key] = true
+		b.WriteString(" " + attribute.Key + `="` + html.EscapeString(value) + `"`)
+	}
+
+	if token.Data == "img" && !seen["src"] {
+		return "", false
+	}
+	if token.Data == "a" && p.linkRel != "" {
+		b.WriteString(` rel="` + p.linkRel + `"`)
+	}
+	return b.String(), true
+}
+
+// safeURL reports whether the URL is relative or uses one of the schemes
+func safeURL(url string, schemes ...string) bool {
+	// Browsers ignore control characters and whitespace in schemes, "java\tscript:" is javascript
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+	if cleaned == "" {
+		return false
+	}
+	m := schemePattern.FindStringSubmatch(cleaned)
+	if m == nil {
+		// A colon before any slash, question mark or hash would be read as a scheme
+		colon := strings.IndexByte(cleaned, ':')
+		return colon < 0 || (strings.IndexAny(cleaned, "/?#") >= 0 && strings.IndexAny(cleaned, "/?#") < colon)
+	}
+	return contains(schemes, strings.ToLower(m[1]))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
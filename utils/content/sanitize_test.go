@@ -0,0 +1,103 @@
+package content
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		// Links and images
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"uppercase scheme", `<a href="JAVASCRIPT:alert(1)">x</a>`, `<a>x</a>`},
+		{"leading spaces", `<a href="  javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"tab in scheme", "<a href=\"java\tscript:alert(1)\">x</a>", `<a>x</a>`},
+		{"newline in scheme", "<a href=\"java\nscript:alert(1)\">x</a>", `<a>x</a>`},
+		{"decimal entity in scheme", `<a href="&#106;avascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"hex entity in scheme", `<a href="jav&#x61;script:alert(1)">x</a>`, `<a>x</a>`},
+		{"named entity colon", `<a href="javascript&colon;alert(1)">x</a>`, `<a>x</a>`},
+		{"data link", `<a href="data:text/html,hi">x</a>`, `<a>x</a>`},
+		{"vbscript link", `<a href="vbscript:msgbox(1)">x</a>`, `<a>x</a>`},
+		{"https link", `<a href="https://example.com/a?b=1&amp;c=2">x</a>`, `<a href="https://example.com/a?b=1&amp;c=2">x</a>`},
+		{"mailto link", `<a href="mailto:alice@example.com">x</a>`, `<a href="mailto:alice@example.com">x</a>`},
+		{"relative link", `<a href="/posts/1#comments">x</a>`, `<a href="/posts/1#comments">x</a>`},
+		{"colon read as a scheme", `<a href="alert:1">x</a>`, `<a>x</a>`},
+		{"colon after a slash", `<a href="./a:b">x</a>`, `<a href="./a:b">x</a>`},
+		{"javascript cite", `<blockquote cite="javascript:alert(1)">x</blockquote>`, `<blockquote>x</blockquote>`},
+		{"javascript image", `<img src="javascript:alert(1)">`, ``},
+		{"entity encoded image", `<img src="&#x6A;avascript:alert(1)" alt="a">`, ``},
+		{"data image", `<img src="data:image/png;base64,AAAA">`, ``},
+		{"mailto image", `<img src="mailto:alice@example.com">`, ``},
+		{"image", `<img src="https://example.com/a.png" alt="a" width="100">`, `<img src="https://example.com/a.png" alt="a" width="100" />`},
+		{"escaped attribute", `<a href="https://example.com" title='"><script>x</script>'>x</a>`,
+			`<a href="https://example.com" title="&#34;&gt;&lt;script&gt;x&lt;/script&gt;">x</a>`},
+		{"duplicate attribute", `<a href="https://example.com" href="javascript:alert(1)">x</a>`, `<a href="https://example.com">x</a>`},
+
+		// Event handlers and styles
+		{"onerror", `<img src="https://example.com/a.png" onerror="alert(1)">`, `<img src="https://example.com/a.png" />`},
+		{"onclick and style", `<a href="/posts/1" onclick="alert(1)" style="color:red">x</a>`, `<a href="/posts/1">x</a>`},
+		{"style url", `<p style="background:url(javascript:alert(1))" onmouseover="x()">hi</p>`, `<p>hi</p>`},
+		{"uppercase handler", `<p ONCLICK="alert(1)">hi</p>`, `<p>hi</p>`},
+		{"style element", `<style>body{display:none}</style>text`, `text`},
+		{"invalid class", `<code class="language-go onclick">x</code>`, `<code>x</code>`},
+		{"language class", `<code class="language-go">x</code>`, `<code class="language-go">x</code>`},
+		{"invalid width", `<img src="https://example.com/a.png" width="100%">`, `<img src="https://example.com/a.png" />`},
+
+		// Dropped elements
+		{"script", `<script>alert(1)</script>after`, `after`},
+		{"split script", `<scr<script>ipt>alert(1)</script>`, `ipt&gt;alert(1)`},
+		{"svg", `<svg><script>alert(1)</script><a href="x">y</a></svg>after`, `after`},
+		{"nested svg", `<svg><svg></svg><p>in</p></svg>after`, `after`},
+		{"svg onload", `<svg onload="alert(1)"/>after`, `after`},
+		{"math", `<math><mi xlink:href="javascript:alert(1)">x</mi></math>after`, `after`},
+		{"math in svg", `<svg><math><mtext><img src=x onerror=alert(1)></mtext></math></svg>after`, `after`},
+		{"template", `<template><img src=x onerror=alert(1)></template>after`, `after`},
+		{"nested template", `<template><template></template><p>in</p></template>after`, `after`},
+		{"plaintext", `<plaintext><b>bold</b>`, ``},
+		{"iframe", `<iframe src="https://example.com"></iframe>after`, `after`},
+		{"unknown element", `<blink>text</blink>`, `text`},
+
+		// Unclosed and misnested tags
+		{"unclosed", `<p><b>unclosed`, `<p><b>unclosed</b></p>`},
+		{"misnested", `<b><i>misnested</b></i>`, `<b><i>misnested</i></b>`},
+		{"stray end tags", `</p></b>stray`, `stray`},
+		{"unclosed paragraphs", `<p>a<p>b`, `<p>a<p>b</p></p>`},
+		{"unclosed script", `<script>alert(1)`, ``},
+		{"unclosed svg", `<svg><p>in`, ``},
+		{"unterminated tag", `text<a href="javascript:alert(1)"`, `text`},
+		{"unterminated comment", `text<!-- <script>alert(1)</script>`, `text`},
+		{"comment", `a<!-- <script>alert(1)</script> -->b`, `ab`},
+		{"text", `1 < 2 & 3 > 2`, `1 &lt; 2 &amp; 3 &gt; 2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PostPolicy.Sanitize(tt.input); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentPolicy(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"link", `<a href="https://example.com" rel="opener">x</a>`, `<a href="https://example.com" rel="nofollow ugc">x</a>`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow ugc">x</a>`},
+		{"image", `<img src="https://example.com/a.png">`, ``},
+		{"heading", `<h1 onclick="alert(1)">title</h1>`, `title`},
+		{"blockquote cite", `<blockquote cite="https://example.com">x</blockquote>`, `<blockquote>x</blockquote>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommentPolicy.Sanitize(tt.input); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+
+	// Past maxEdits insertions and deletions, the texts are reported as entirely replaced to bound memory use:
+	// the states kept to build the edit script take about 2*maxEdits² bytes
+	maxEdits = 1000
+)
+
+// Chunk is a run of consecutive tokens that are kept, inserted or deleted
+type Chunk struct {
+	Op   string `json:"op" example:"insert"`
+	Text string `json:"text" example:"new words "`
+}
+
+// Lines compares two texts line by line, with Windows line breaks read as "\n". Every line of a chunk keeps its line break.
+func Lines(a, b string) []Chunk {
+	return compare(splitLines(a), splitLines(b))
+}
+
+// Words compares two texts word by word. Whitespace is kept with the word before it.
+func Words(a, b string) []Chunk {
+	return compare(splitWords(a), splitWords(b))
+}
+
+// HasChanges reports whether a diff contains insertions or deletions
+func HasChanges(chunks []Chunk) bool {
+	for _, chunk := range chunks {
+		if chunk.Op != OpEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func splitWords(text string) []string {
+	var tokens []string
+	start := 0
+	inSpace := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if !space && inSpace {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// compare runs the Myers algorithm on the tokens left once the common prefix and suffix are removed
+func compare(a, b []string) []Chunk {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var chunks []Chunk
+	chunks = appendTokens(chunks, OpEqual, a[:prefix])
+	for _, e := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		chunks = appendTokens(chunks, e.op, []string{e.token})
+	}
+	chunks = appendTokens(chunks, OpEqual, a[len(a)-suffix:])
+	return chunks
+}
+
+type edit struct {
+	op    string
+	token string
+}
+
+// myers returns the shortest edit script turning a into b, following "An O(ND) Difference Algorithm" by E. Myers
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	max := n + m
+	if max > maxEdits {
+		max = maxEdits
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d][(k+d)/2] keeps the furthest x reached on diagonal k after d edits. Only the diagonals -d, -d+2, ..., d
+	// can be reached with d edits, so d+1 values are kept.
+	trace := make([][]int32, 0, max+1)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+		}
+		state := make([]int32, d+1)
+		for k := -d; k <= d; k += 2 {
+			state[(k+d)/2] = int32(v[offset+k])
+		}
+		trace = append(trace, state)
+		for k := -d; k <= d; k += 2 {
+			if x := v[offset+k]; x >= n && x-k >= m {
+				return backtrack(a, b, trace, k, d)
+			}
+		}
+	}
+
+	edits := make([]edit, 0, n+m)
+	for _, token := range a {
+		edits = append(edits, edit{OpDelete, token})
+	}
+	for _, token := range b {
+		edits = append(edits, edit{OpInsert, token})
+	}
+	return edits
+}
+
+// backtrack walks the saved states back from diagonal k after d edits to build the edit script
+func backtrack(a, b []string, trace [][]int32, k, d int) []edit {
+	x := int(trace[d][(k+d)/2])
+	y := x - k
+	edits := make([]edit, 0, x+y)
+
+	for ; d > 0; d-- {
+		// The diagonal k' is at index (k'+d-1)/2 of the previous state
+		prev := trace[d-1]
+		var prevK int
+		if k == -d || (k != d && prev[(k+d-2)/2] < prev[(k+d)/2]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := int(prev[(prevK+d-1)/2])
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{OpEqual, a[x]})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{OpInsert, b[y]})
+		} else {
+			x--
+			edits = append(edits, edit{OpDelete, a[x]})
+		}
+		k = prevK
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, edit{OpEqual, a[x]})
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// appendTokens adds the tokens to the last chunk when it has the same operation, or starts a new chunk
+func appendTokens(chunks []Chunk, op string, tokens []string) []Chunk {
+	if len(tokens) == 0 {
+		return chunks
+	}
+	text := strings.Join(tokens, "")
+	if last := len(chunks) - 1; last >= 0 && chunks[last].Op == op {
+		chunks[last].Text += text
+		return chunks
+	}
+	return append(chunks, Chunk{Op: op, Text: text})
+}
@@ -0,0 +1,60 @@
+package feed
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Feed is a list of posts, rendered as RSS 2.0, Atom or JSON Feed
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the page of the blog the feed syndicates, SelfURL the URL the feed is served at
+	Link    string
+	SelfURL string
+	Updated time.Time
+	Items   []Item
+}
+
+type Item struct {
+	// ID is a permanent identifier of the post, which does not change with its slug
+	ID          string
+	Title       string
+	Link        string
+	Summary     string
+	ContentHTML string
+	Author      string
+	Categories  []string
+	Published   time.Time
+	Updated     time.Time
+	Image       *Image
+}
+
+type Image struct {
+	URL      string
+	MimeType string
+	Size     int64
+}
+
+// Format renders a feed in one of the syndication formats
+type Format struct {
+	ContentType string
+	Render      func(Feed) ([]byte, error)
+}
+
+// Formats are the feed formats by file name
+var Formats = map[string]Format{
+	"rss.xml":   {ContentType: "application/rss+xml; charset=utf-8", Render: RSS},
+	"atom.xml":  {ContentType: "application/atom+xml; charset=utf-8", Render: Atom},
+	"feed.json": {ContentType: "application/feed+json; charset=utf-8", Render: JSON},
+}
+
+// TagURI returns a tag URI (RFC 4151) identifying an entity of the site, e.g. tag:blog.example.com,2025-01-02:post/12
+func TagURI(siteURL string, created time.Time, entity string, id uint) string {
+	authority := "localhost"
+	if parsed, err := url.Parse(siteURL); err == nil && parsed.Hostname() != "" {
+		authority = parsed.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:%s/%d", authority, created.UTC().Format("2006-01-02"), entity, id)
+}
@@ -34,6 +34,12 @@ func FormatValidationError(err error, obj interface{}) []map[string]string {
 				msg = jsonField + " must be a valid email"
 			case "min":
 				msg = jsonField + " value is too short"
+			case "max":
+				msg = jsonField + " value is too long"
+			case "url", "url|len=0":
+				msg = jsonField + " must be a valid URL"
+			case "http_url", "http_url|len=0":
+				msg = jsonField + " must be a valid http or https URL"
 			case "strong_password":
 				msg = jsonField + " must contain uppercase, lowercase, number, and special character"
 			default:
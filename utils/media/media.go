@@ -0,0 +1,36 @@
+package media
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	mediaModel "go-blog/models/media"
+	"go-blog/models/user"
+	authUtils "go-blog/utils/auth"
+	"path"
+	"strings"
+	"time"
+)
+
+// CanManageMedia reports whether the user may update, delete or use the media in a post: admins can manage any
+// media, authors only their own uploads
+func CanManageMedia(userModel user.User, media mediaModel.Media) bool {
+	if authUtils.IsAdmin(userModel) {
+		return true
+	}
+	return media.UploaderID != nil && *media.UploaderID == userModel.ID
+}
+
+// NewStorageKey returns a random key for a new file, grouped by month of upload, e.g. 2025/01/4f9a...c2e7.jpg.
+// The name given by the uploader is never used, so that keys are safe in URLs and file systems.
+func NewStorageKey(extension string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("2006/01/") + hex.EncodeToString(random) + extension, nil
+}
+
+// VariantStorageKey returns the key of a variant of the file stored under key
+func VariantStorageKey(key, name, extension string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "-" + name + extension
+}
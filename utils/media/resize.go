@@ -0,0 +1,78 @@
+package media
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// contribution is the share of a source pixel in a destination pixel
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// toRGBA converts an image to premultiplied RGBA, in which pixels can be averaged without darkening transparent
+// edges
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(bounds)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// scale resizes the area r of src to width×height. Each destination pixel is the average of the source pixels
+// it covers, which gives smooth results when scaling down. Rows are scaled one at a time to bound memory use.
+func scale(src *image.RGBA, r image.Rectangle, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	columns := contributions(r.Min.X-src.Rect.Min.X, r.Dx(), width)
+	rows := contributions(r.Min.Y-src.Rect.Min.Y, r.Dy(), height)
+
+	row := make([]float64, width*4)
+	sum := make([]float64, width*4)
+	for y, sources := range rows {
+		clear(sum)
+		for _, source := range sources {
+			// Scale the source row horizontally, then add its share to the destination row
+			clear(row)
+			offset := source.index * src.Stride
+			for x, columnSources := range columns {
+				for _, column := range columnSources {
+					pixel := src.Pix[offset+column.index*4 : offset+column.index*4+4]
+					for c := 0; c < 4; c++ {
+						row[x*4+c] += float64(pixel[c]) * column.weight
+					}
+				}
+			}
+			for i := range sum {
+				sum[i] += row[i] * source.weight
+			}
+		}
+
+		pixels := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+		for i := range pixels {
+			pixels[i] = uint8(math.Min(255, math.Max(0, math.Round(sum[i]))))
+		}
+	}
+	return dst
+}
+
+// contributions returns, for each of the n destination pixels, the source pixels from start to start+length it
+// covers and their share of it
+func contributions(start, length, n int) [][]contribution {
+	ratio := float64(length) / float64(n)
+	result := make([][]contribution, n)
+	for i := range result {
+		low, high := float64(i)*ratio, float64(i+1)*ratio
+		for j := int(low); float64(j) < high && j < length; j++ {
+			overlap := math.Min(high, float64(j+1)) - math.Max(low, float64(j))
+			if overlap > 0 {
+				result[i] = append(result[i], contribution{index: start + j, weight: overlap / ratio})
+			}
+		}
+	}
+	return result
+}
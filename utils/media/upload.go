@@ -0,0 +1,151 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	mediaModel "go-blog/models/media"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrImageTooLarge   = errors.New("image too large")
+)
+
+// allowedTypes maps the MIME types accepted for upload, as sniffed from the content of the files, to the
+// extension of the stored files. SVG is refused, as it can embed scripts.
+var allowedTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// decodableTypes are the image types the standard library decodes, and for which variants are generated
+var decodableTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true}
+
+var variantSizes = []struct {
+	name          string
+	width, height int
+	crop          bool
+}{
+	{mediaModel.VariantThumbnail, 320, 320, true},
+	{mediaModel.VariantMedium, 800, 800, false},
+	{mediaModel.VariantLarge, 1600, 1600, false},
+}
+
+// File is an uploaded file, or a variant generated from it
+type File struct {
+	Data      []byte
+	MimeType  string
+	Extension string
+	// Width and Height are 0 for files which are not decodable images
+	Width  int
+	Height int
+}
+
+// Variant is a resized copy of an uploaded image
+type Variant struct {
+	Name string
+	File
+}
+
+// Inspect sniffs the type of an uploaded file from its content, ignoring the name and type sent by the client,
+// and reads the size of images. Images of more than maxPixels pixels are refused.
+func Inspect(data []byte, maxPixels int) (File, error) {
+	mimeType := http.DetectContentType(data)
+	extension, ok := allowedTypes[mimeType]
+	if !ok {
+		return File{}, fmt.Errorf("%w: %s", ErrUnsupportedType, mimeType)
+	}
+
+	file := File{Data: data, MimeType: mimeType, Extension: extension}
+	if !decodableTypes[mimeType] {
+		return file, nil
+	}
+
+	// The header is enough to know the size, so that huge images are refused before being decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return File{}, fmt.Errorf("%w: invalid %s image", ErrUnsupportedType, mimeType)
+	}
+	if config.Width*config.Height > maxPixels {
+		return File{}, ErrImageTooLarge
+	}
+	file.Width, file.Height = config.Width, config.Height
+	return file, nil
+}
+
+// GenerateVariants returns the resized copies of an image. Images are never scaled up: an image smaller than
+// a variant has no such variant, except for the thumbnail which is then the largest square of the image.
+// Files which are not decodable images have no variants.
+func GenerateVariants(file File) ([]Variant, error) {
+	if !decodableTypes[file.MimeType] {
+		return nil, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(file.Data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+	source := toRGBA(decoded)
+	bounds := source.Bounds()
+
+	var variants []Variant
+	for _, size := range variantSizes {
+		area := bounds
+		width, height := fit(bounds.Dx(), bounds.Dy(), size.width, size.height)
+		if size.crop {
+			side := min(bounds.Dx(), bounds.Dy())
+			x := bounds.Min.X + (bounds.Dx()-side)/2
+			y := bounds.Min.Y + (bounds.Dy()-side)/2
+			area = image.Rect(x, y, x+side, y+side)
+			width, height = min(side, size.width), min(side, size.height)
+		} else if width == bounds.Dx() && height == bounds.Dy() {
+			continue
+		}
+
+		variant, err := encode(scale(source, area, width, height), file)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, Variant{Name: size.name, File: variant})
+	}
+	return variants, nil
+}
+
+// fit returns the size of an image scaled down to fit in maxWidth×maxHeight, keeping its aspect ratio
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, max(1, height*maxWidth/width)
+	}
+	return max(1, width*maxHeight/height), maxHeight
+}
+
+// encode writes the variant as a JPEG when the original is a JPEG, and as a PNG otherwise to keep transparency
+func encode(img *image.RGBA, original File) (File, error) {
+	var buffer bytes.Buffer
+	file := File{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	if original.MimeType == "image/jpeg" {
+		if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 85}); err != nil {
+			return File{}, fmt.Errorf("error encoding variant: %w", err)
+		}
+		file.MimeType, file.Extension = "image/jpeg", ".jpg"
+	} else {
+		if err := png.Encode(&buffer, img); err != nil {
+			return File{}, fmt.Errorf("error encoding variant: %w", err)
+		}
+		file.MimeType, file.Extension = "image/png", ".png"
+	}
+	file.Data = buffer.Bytes()
+	return file, nil
+}
@@ -0,0 +1,146 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-blog/services/config"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discovery documents and key sets are fetched again after cacheLifetime
+const cacheLifetime = time.Hour
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Metadata is the part of the provider discovery document used by the login flow
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse is the response of the token endpoint
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type cachedMetadata struct {
+	metadata  Metadata
+	fetchedAt time.Time
+}
+
+var (
+	metadataMu    sync.Mutex
+	metadataCache = map[string]cachedMetadata{}
+)
+
+// Discover fetches the discovery document of the provider, cached for an hour
+func Discover(provider config.OIDCProvider) (Metadata, error) {
+	metadataMu.Lock()
+	cached, ok := metadataCache[provider.Issuer]
+	metadataMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < cacheLifetime {
+		return cached.metadata, nil
+	}
+
+	var metadata Metadata
+	if err := getJSON(provider.Issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return metadata, err
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != provider.Issuer {
+		return metadata, fmt.Errorf("discovery document issuer %q does not match %q", metadata.Issuer, provider.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return metadata, errors.New("incomplete discovery document")
+	}
+
+	metadataMu.Lock()
+	metadataCache[provider.Issuer] = cachedMetadata{metadata: metadata, fetchedAt: time.Now()}
+	metadataMu.Unlock()
+	return metadata, nil
+}
+
+// AuthCodeURL builds the URL of the authorization endpoint starting an authorization code flow with PKCE
+func AuthCodeURL(metadata Metadata, provider config.OIDCProvider, state, nonce, codeVerifier string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.ClientID)
+	query.Set("redirect_uri", provider.RedirectURL)
+	query.Set("scope", strings.Join(provider.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange trades an authorization code and its PKCE verifier for tokens
+func Exchange(metadata Metadata, provider config.OIDCProvider, code, codeVerifier string) (TokenResponse, error) {
+	var tokens TokenResponse
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", provider.ClientID)
+
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokens, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if provider.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return tokens, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return tokens, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return tokens, fmt.Errorf("token endpoint returned %s: %s", resp.Status, body)
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return tokens, err
+	}
+	if tokens.IDToken == "" {
+		return tokens, errors.New("token response has no id_token")
+	}
+	return tokens, nil
+}
+
+func getJSON(url string, target interface{}) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(target)
+}
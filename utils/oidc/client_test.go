@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"go-blog/services/config"
+	"go-blog/utils/oidc/oidctest"
+	"testing"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, config.OIDCProvider) {
+	t.Helper()
+	stub, err := oidctest.NewProvider("go-blog", "user-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stub.Close)
+	stub.Claims["email"] = "alice@example.com"
+	stub.Claims["email_verified"] = true
+
+	return stub, config.OIDCProvider{
+		Name:        "stub",
+		Issuer:      stub.Issuer(),
+		ClientID:    "go-blog",
+		RedirectURL: "http://localhost:8080/v1/oidc/stub/callback",
+		Scopes:      []string{"openid", "email"},
+	}
+}
+
+// authorize logs in at the stub provider and returns the code and state sent back to the client
+func authorize(t *testing.T, stub *oidctest.Provider, authURL string) (string, string) {
+	t.Helper()
+	code, state, err := stub.Login(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, state
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	stub, provider := newTestProvider(t)
+
+	metadata, err := Discover(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, _ := NewCodeVerifier()
+	code, state := authorize(t, stub, AuthCodeURL(metadata, provider, "state-1", "nonce-1", verifier))
+	if state != "state-1" {
+		t.Errorf("expected the state to be sent back, got %q", state)
+	}
+
+	tokens, err := Exchange(metadata, provider, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := VerifyIDToken(metadata, provider, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-42" || claims.Email != "alice@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if _, err := Exchange(metadata, provider, code, verifier); err == nil {
+		t.Error("expected a code to be usable only once")
+	}
+}
+
+func TestExchangeRequiresCodeVerifier(t *testing.T) {
+	stub, provider := newTestProvider(t)
+
+	metadata, err := Discover(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, _ := NewCodeVerifier()
+	code, _ := authorize(t, stub, AuthCodeURL(metadata, provider, "state", "nonce", verifier))
+
+	other, _ := NewCodeVerifier()
+	if _, err := Exchange(metadata, provider, code, other); err == nil {
+		t.Error("expected the exchange to fail with another code verifier")
+	}
+}
+
+func TestVerifyIDTokenChecksNonceAndAudience(t *testing.T) {
+	stub, provider := newTestProvider(t)
+
+	metadata, err := Discover(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, _ := NewCodeVerifier()
+	code, _ := authorize(t, stub, AuthCodeURL(metadata, provider, "state", "nonce", verifier))
+	tokens, err := Exchange(metadata, provider, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyIDToken(metadata, provider, tokens.IDToken, "another-nonce"); err == nil {
+		t.Error("expected an ID token with another nonce to be rejected")
+	}
+
+	otherClient := provider
+	otherClient.ClientID = "another-client"
+	if _, err := VerifyIDToken(metadata, otherClient, tokens.IDToken, "nonce"); err == nil {
+		t.Error("expected an ID token issued to another client to be rejected")
+	}
+}
@@ -4,13 +4,23 @@ import (
 	"errors"
 	commentDTO "go-blog/dto/post"
 	commentModel "go-blog/models/post"
+	"go-blog/models/user"
 	"go-blog/services/config"
+	authUtils "go-blog/utils/auth"
 )
 
-// FetchCommentsForPost Helper function to fetch comments for a post
-func FetchCommentsForPost(postID string) ([]commentModel.Comment, error) {
+// FetchCommentsForPost Helper function to fetch the visible comments for a post:
+// approved comments, plus the pending ones written by the viewer when one is given
+func FetchCommentsForPost(postID string, viewerID *uint) ([]commentModel.Comment, error) {
 	var comments []commentModel.Comment
-	err := config.Db.Preload("User").Where("post_id = ?", postID).Order("created_at ASC").Find(&comments).Error
+	query := config.Db.Preload("User").Where("post_id = ?", postID)
+	if viewerID != nil {
+		query = query.Where("status = ? OR (status = ? AND user_id = ?)",
+			commentModel.CommentStatusApproved, commentModel.CommentStatusPending, *viewerID)
+	} else {
+		query = query.Where("status = ?", commentModel.CommentStatusApproved)
+	}
+	err := query.Order("created_at ASC").Find(&comments).Error
 	return comments, err
 }
 
@@ -27,17 +37,15 @@ func BuildCommentTree(allComments []commentModel.Comment) []*commentDTO.CommentR
 
 	var roots []*commentDTO.CommentResponse
 
-	// Étape 2 : remplir la hiérarchie
+	// Étape 2 : remplir la hiérarchie, les réponses dont le parent n'est pas visible devenant des racines
 	for _, c := range allComments {
 		if c.ParentID != nil {
-			parent, exists := commentMap[*c.ParentID]
-			if exists {
-				child := commentMap[c.ID]
-				parent.Children = append(parent.Children, child)
+			if parent, exists := commentMap[*c.ParentID]; exists {
+				parent.Children = append(parent.Children, commentMap[c.ID])
+				continue
 			}
-		} else {
-			roots = append(roots, commentMap[c.ID])
 		}
+		roots = append(roots, commentMap[c.ID])
 	}
 
 	return roots
@@ -60,3 +68,17 @@ func PaginateComments(roots []*commentDTO.CommentResponse, page, limit int) ([]*
 
 	return roots[start:end], total, nil
 }
+
+// CanManageComment reports whether the user may update or delete the comment: admins can manage any comment, others only their own
+func CanManageComment(userModel user.User, comment commentModel.Comment) bool {
+	return authUtils.IsAdmin(userModel) || comment.UserID == userModel.ID
+}
+
+// IsValidCommentStatus reports whether the status is one of the known comment statuses
+func IsValidCommentStatus(status string) bool {
+	switch status {
+	case commentModel.CommentStatusPending, commentModel.CommentStatusApproved, commentModel.CommentStatusRejected:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-blog/utils"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Precondition is the version of a resource a client based its change on
+type Precondition struct {
+	Version uint
+	// Any is set by "If-Match: *", which accepts any version
+	Any bool
+	// FromHeader is set when the version comes from the If-Match header rather than a version field
+	FromHeader bool
+}
+
+// Matches reports whether the current version of the resource is the one the client expects
+func (p Precondition) Matches(version uint) bool {
+	return p.Any || p.Version == version
+}
+
+// ETag returns the entity tag of a version of a resource
+func ETag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// SetETag sets the ETag header of the response to the version of the resource
+func SetETag(ctx *gin.Context, version uint) {
+	ctx.Header("ETag", ETag(version))
+}
+
+// NotModified responds with 304 Not Modified when the If-None-Match header matches the version of the resource
+func NotModified(ctx *gin.Context, version uint) bool {
+	for _, tag := range strings.Split(ctx.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == ETag(version) {
+			SetETag(ctx, version)
+			ctx.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ReadPrecondition reads the expected version from the If-Match header, then from the version field of the body,
+// then from the version query parameter
+func ReadPrecondition(ctx *gin.Context, bodyVersion *uint) (Precondition, bool) {
+	if header := strings.TrimSpace(ctx.GetHeader("If-Match")); header != "" {
+		if header == "*" {
+			return Precondition{Any: true, FromHeader: true}, true
+		}
+		// An unknown entity tag matches no version
+		version, _ := strconv.ParseUint(strings.Trim(header, `"`), 10, 0)
+		return Precondition{Version: uint(version), FromHeader: true}, true
+	}
+	if bodyVersion != nil {
+		return Precondition{Version: *bodyVersion}, true
+	}
+	if query := ctx.Query("version"); query != "" {
+		version, _ := strconv.ParseUint(query, 10, 0)
+		return Precondition{Version: uint(version)}, true
+	}
+	return Precondition{}, false
+}
+
+// RequirePrecondition reads the expected version and responds with 428 Precondition Required when there is none
+func RequirePrecondition(ctx *gin.Context, bodyVersion *uint) (Precondition, bool) {
+	precondition, ok := ReadPrecondition(ctx, bodyVersion)
+	if !ok {
+		ctx.JSON(http.StatusPreconditionRequired, utils.NewErrorResponse("Send the version of the resource in an If-Match header or a version field"))
+		return precondition, false
+	}
+	return precondition, true
+}
+
+// RespondVersionConflict responds with the current representation of a resource that changed since the expected
+// version: 412 Precondition Failed for an If-Match header, 409 Conflict for a version field
+func RespondVersionConflict(ctx *gin.Context, precondition Precondition, version uint, current interface{}) {
+	status := http.StatusConflict
+	if precondition.FromHeader {
+		status = http.StatusPreconditionFailed
+	}
+	SetETag(ctx, version)
+	ctx.JSON(status, utils.NewConflictResponse(
+		fmt.Sprintf("The resource was modified, its current version is %d", version), current))
+}
+
+// ErrVersionConflict is returned by ClaimVersion when the resource is not at the version the client expects
+var ErrVersionConflict = errors.New("the resource was modified since the expected version")
+
+// ClaimVersion increments the version of a resource, only if it still is the given version and the one the client
+// expects. It must run in the transaction saving the changes, so that they are only kept when the claim succeeds.
+// It returns ErrVersionConflict when another change was saved in the meantime.
+func ClaimVersion(tx *gorm.DB, model interface{}, id, version uint, precondition Precondition) error {
+	if !precondition.Matches(version) {
+		return ErrVersionConflict
+	}
+	result := tx.Model(model).
+		Where("id = ? AND version = ?", id, version).
+		UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	postModel "go-blog/models/post"
+	"go-blog/models/user"
 	"go-blog/services/config"
 	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
 	"gorm.io/gorm"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -18,18 +22,18 @@ const (
 	DefaultLimit = 10
 )
 
-func DeleteCategoryRecursively(category *postModel.Category) error {
+func DeleteCategoryRecursively(tx *gorm.DB, category *postModel.Category) error {
 	// Charger récursivement tous les enfants
 	for i := range category.Children {
-		if err := config.Db.Preload("Children").First(&category.Children[i], category.Children[i].ID).Error; err != nil {
+		if err := tx.Preload("Children").First(&category.Children[i], category.Children[i].ID).Error; err != nil {
 			return err
 		}
-		if err := DeleteCategoryRecursively(&category.Children[i]); err != nil {
+		if err := DeleteCategoryRecursively(tx, &category.Children[i]); err != nil {
 			return err
 		}
 	}
 	// Supprimer la catégorie
-	return config.Db.Delete(category).Error
+	return tx.Delete(category).Error
 }
 
 // BindAndValidateJSON handles JSON binding and validation for request objects
@@ -74,7 +78,10 @@ func GetOrCreateCategory(name string) (postModel.Category, error) {
 	result := config.Db.Where("name = ?", name).First(&category)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		category = postModel.Category{Name: name}
-		if err := config.Db.Create(&category).Error; err != nil {
+		if err := SaveWithUniqueSlug(postModel.SlugEntityCategory, name, "category", 0, func(slug string) error {
+			category.Slug = slug
+			return config.Db.Create(&category).Error
+		}); err != nil {
 			return category, fmt.Errorf("failed to create category %s: %w", name, err)
 		}
 		return category, nil
@@ -85,3 +92,121 @@ func GetOrCreateCategory(name string) (postModel.Category, error) {
 
 	return category, nil
 }
+
+// CanManagePost reports whether the user may update or delete the post: admins can manage any post, authors only their own
+func CanManagePost(userModel user.User, post postModel.Post) bool {
+	if authUtils.IsAdmin(userModel) {
+		return true
+	}
+	return post.AuthorID != nil && *post.AuthorID == userModel.ID
+}
+
+// ApplyPostStatus sets the status and publication date of the post, keeping the current status when none is given
+func ApplyPostStatus(post *postModel.Post, status string, publishedAt *time.Time) error {
+	if status == "" {
+		status = post.Status
+	}
+	if status == "" {
+		status = postModel.PostStatusDraft
+	}
+
+	now := time.Now()
+	switch status {
+	case postModel.PostStatusScheduled:
+		if publishedAt == nil {
+			publishedAt = post.PublishedAt
+		}
+		if publishedAt == nil || !publishedAt.After(now) {
+			return errors.New("published_at must be a future date for a scheduled post")
+		}
+		post.PublishedAt = publishedAt
+	case postModel.PostStatusPublished:
+		if publishedAt != nil {
+			if publishedAt.After(now) {
+				return errors.New("published_at cannot be in the future for a published post, use SCHEDULED instead")
+			}
+			post.PublishedAt = publishedAt
+		} else if post.PublishedAt == nil || post.Status != postModel.PostStatusPublished {
+			post.PublishedAt = &now
+		}
+	}
+
+	post.Status = status
+	return nil
+}
+
+// ApplyPostVisibility restricts the query to the posts the viewer may see:
+// published posts for everyone, plus their own posts for authors and every post for admins
+func ApplyPostVisibility(query *gorm.DB, viewer *user.User) *gorm.DB {
+	switch {
+	case viewer != nil && authUtils.IsAdmin(*viewer):
+		return query
+	case viewer != nil:
+		return query.Where("(posts.status = ? OR posts.author_id = ?)", postModel.PostStatusPublished, viewer.ID)
+	default:
+		return query.Where("posts.status = ?", postModel.PostStatusPublished)
+	}
+}
+
+// CanViewPost reports whether the viewer may read the post, following the same rules as ApplyPostVisibility
+func CanViewPost(viewer *user.User, post postModel.Post) bool {
+	if post.Status == postModel.PostStatusPublished {
+		return true
+	}
+	return viewer != nil && CanManagePost(*viewer, post)
+}
+
+// IsValidPostStatus reports whether the status is one of the known post statuses
+func IsValidPostStatus(status string) bool {
+	switch status {
+	case postModel.PostStatusDraft, postModel.PostStatusInReview, postModel.PostStatusScheduled,
+		postModel.PostStatusPublished, postModel.PostStatusArchived:
+		return true
+	}
+	return false
+}
+
+// ParseUintList parses a comma-separated list of IDs such as "1,2,3"
+func ParseUintList(param string) ([]uint, error) {
+	if param == "" {
+		return nil, nil
+	}
+	var ids []uint
+	for _, part := range strings.Split(param, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// ParseDateParam parses an optional date query parameter, either RFC 3339 or YYYY-MM-DD
+func ParseDateParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return &date, nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// ParseDateEndParam parses the optional end of a date range into an exclusive bound.
+// A YYYY-MM-DD date includes the whole day, so it ends at the start of the next day.
+func ParseDateEndParam(value string) (*time.Time, error) {
+	date, err := ParseDateParam(value)
+	if err != nil || date == nil {
+		return date, err
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		end := date.AddDate(0, 0, 1)
+		return &end, nil
+	}
+	return date, nil
+}
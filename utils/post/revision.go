@@ -0,0 +1,105 @@
+package post
+
+import (
+	postModel "go-blog/models/post"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordRevision saves a snapshot of the post, with its categories and tags loaded, as its next revision.
+// It must run in the transaction saving the post, which it locks until the end of the transaction so that
+// concurrent changes of the post are numbered one after the other.
+func RecordRevision(tx *gorm.DB, post postModel.Post, editorID *uint, restoredFrom *int) (postModel.PostRevision, error) {
+	revision := newRevision(post, editorID)
+	revision.RestoredFrom = restoredFrom
+
+	if err := lockPost(tx, post.ID); err != nil {
+		return revision, err
+	}
+
+	var last int
+	if err := tx.Model(&postModel.PostRevision{}).
+		Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&last).Error; err != nil {
+		return revision, err
+	}
+	revision.Number = last + 1
+	return revision, tx.Create(&revision).Error
+}
+
+// EnsureInitialRevision saves the current state of a post created before revisions were recorded as its first
+// revision, so that it can be restored after the post is updated. Like RecordRevision, it locks the post.
+func EnsureInitialRevision(tx *gorm.DB, post postModel.Post) error {
+	if err := lockPost(tx, post.ID); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := tx.Model(&postModel.PostRevision{}).Where("post_id = ?", post.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	revision := newRevision(post, post.AuthorID)
+	revision.Number = 1
+	revision.CreatedAt = post.UpdatedAt
+	return tx.Create(&revision).Error
+}
+
+// lockPost locks the row of the post with SELECT ... FOR UPDATE until the end of the transaction
+func lockPost(tx *gorm.DB, postID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&postModel.Post{}, postID).Error
+}
+
+func newRevision(post postModel.Post, editorID *uint) postModel.PostRevision {
+	categoryIDs := make([]uint, len(post.Categories))
+	for i, category := range post.Categories {
+		categoryIDs[i] = category.ID
+	}
+
+	tags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		tags[i] = tag.Name
+	}
+
+	return postModel.PostRevision{
+		PostID:        post.ID,
+		Title:         post.Title,
+		Excerpt:       post.Excerpt,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		Status:        post.Status,
+		CategoryIDs:   categoryIDs,
+		Tags:          tags,
+		EditorID:      editorID,
+		CoverImageID:  post.CoverImageID,
+	}
+}
+
+// CompareSets returns the values of b missing from a, and the values of a missing from b
+func CompareSets[T comparable](a, b []T) (added, removed []T) {
+	inA := make(map[T]bool, len(a))
+	for _, v := range a {
+		inA[v] = true
+	}
+	inB := make(map[T]bool, len(b))
+	for _, v := range b {
+		inB[v] = true
+	}
+
+	added, removed = []T{}, []T{}
+	for _, v := range b {
+		if !inA[v] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range a {
+		if !inB[v] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,163 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxSlugLength = 180
+	// maxSlugAttempts is how many times a save is retried when concurrent saves keep taking its slug
+	maxSlugAttempts = 5
+	// mysqlDuplicateEntry is the MySQL error raised when a unique index rejects a value
+	mysqlDuplicateEntry = 1062
+)
+
+// Letters that are not decomposed by Unicode normalization
+var slugReplacer = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "Æ", "ae", "œ", "oe", "Œ", "oe",
+	"ø", "o", "Ø", "o", "đ", "d", "Đ", "d", "ł", "l", "Ł", "l",
+	"þ", "th", "Þ", "th", "&", " and ",
+)
+
+// Slugify builds a URL friendly slug from the given text, transliterating accented characters
+func Slugify(text string) string {
+	text = slugReplacer.Replace(text)
+
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if transliterated, _, err := transform.String(stripAccents, text); err == nil {
+		text = transliterated
+	}
+
+	var builder strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			builder.WriteRune(r)
+			lastDash = false
+		case !lastDash:
+			builder.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	slug := strings.Trim(builder.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
+
+// GenerateUniqueSlug returns a slug for the given text that is not used by another post or category,
+// appending a numeric suffix on collision. fallback is used when the text has no usable characters.
+func GenerateUniqueSlug(entityType, text, fallback string, excludeID uint) (string, error) {
+	base := Slugify(text)
+	if base == "" {
+		base = fallback
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		taken, err := isSlugTaken(entityType, candidate, excludeID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// SaveWithUniqueSlug generates a unique slug for the given text and saves the entity with it. The slug can be taken
+// by a concurrent save between the check and the write, in which case the unique index rejects it and the save is
+// retried with a new slug.
+func SaveWithUniqueSlug(entityType, text, fallback string, excludeID uint, save func(slug string) error) error {
+	for attempt := 1; ; attempt++ {
+		slug, err := GenerateUniqueSlug(entityType, text, fallback, excludeID)
+		if err != nil {
+			return err
+		}
+		if err = save(slug); err == nil || !IsSlugConflict(err) || attempt == maxSlugAttempts {
+			return err
+		}
+	}
+}
+
+// IsSlugConflict reports whether the error is a slug rejected by the unique index of the posts or categories table
+func IsSlugConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry && strings.Contains(mysqlErr.Message, "slug")
+}
+
+func isSlugTaken(entityType, slug string, excludeID uint) (bool, error) {
+	var model interface{}
+	switch entityType {
+	case postModel.SlugEntityPost:
+		model = &postModel.Post{}
+	case postModel.SlugEntityCategory:
+		model = &postModel.Category{}
+	default:
+		return false, fmt.Errorf("unknown slug entity type %s", entityType)
+	}
+
+	var count int64
+	if err := config.Db.Unscoped().Model(model).
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	// A slug still redirecting to another entity cannot be reused
+	if err := config.Db.Model(&postModel.SlugRedirect{}).
+		Where("entity_type = ? AND old_slug = ? AND entity_id <> ?", entityType, slug, excludeID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RecordSlugRedirect keeps the old slug of an entity so that it redirects to the new one, using db to join the transaction saving the entity
+func RecordSlugRedirect(db *gorm.DB, entityType, oldSlug string, entityID uint) error {
+	if oldSlug == "" {
+		return nil
+	}
+
+	var redirect postModel.SlugRedirect
+	err := db.Where("entity_type = ? AND old_slug = ?", entityType, oldSlug).First(&redirect).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.Create(&postModel.SlugRedirect{
+			EntityType: entityType,
+			OldSlug:    oldSlug,
+			EntityID:   entityID,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	redirect.EntityID = entityID
+	return db.Save(&redirect).Error
+}
+
+// FindSlugRedirect returns the ID of the entity an old slug points to
+func FindSlugRedirect(entityType, oldSlug string) (uint, error) {
+	var redirect postModel.SlugRedirect
+	if err := config.Db.Where("entity_type = ? AND old_slug = ?", entityType, oldSlug).First(&redirect).Error; err != nil {
+		return 0, err
+	}
+	return redirect.EntityID, nil
+}
@@ -0,0 +1,81 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	postModel "go-blog/models/post"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// GetOrCreateTags returns the tags matching the given names, creating the missing ones.
+// Names are matched on their slug so "Go Lang" and "go-lang" are the same tag.
+// It runs in the transaction saving the post, so that a failed save leaves no new tag behind.
+func GetOrCreateTags(tx *gorm.DB, names []string) ([]postModel.Tag, error) {
+	tags := make([]postModel.Tag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		slug := Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		tag, err := findTag(tx, slug)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The tag may be created by a concurrent request meanwhile: the insert is then skipped
+			// and the tag it created is read again
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&postModel.Tag{Name: name, Slug: slug}).Error; err != nil {
+				return nil, fmt.Errorf("failed to create tag %s: %w", name, err)
+			}
+			tag, err = findTag(tx, slug)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error looking for tag %s: %w", name, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// findTag reads the tag with a locking read, which sees the tags committed after the transaction started
+func findTag(tx *gorm.DB, slug string) (postModel.Tag, error) {
+	var tag postModel.Tag
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("slug = ?", slug).First(&tag).Error
+	return tag, err
+}
+
+// ParseTagSlugs turns a comma-separated list of tag names or slugs into slugs
+func ParseTagSlugs(param string) []string {
+	if param == "" {
+		return nil
+	}
+	var slugs []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(param, ",") {
+		if slug := Slugify(name); slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs
+}
+
+// ApplyTagFilter restricts the post query to the posts having any (or all, when matchAll is set) of the tags
+func ApplyTagFilter(query *gorm.DB, slugs []string, matchAll bool) *gorm.DB {
+	if len(slugs) == 0 {
+		return query
+	}
+	if matchAll {
+		return query.Where(`posts.id IN (
+			SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+			WHERE t.slug IN ? GROUP BY pt.post_id HAVING COUNT(DISTINCT t.id) = ?)`, slugs, len(slugs))
+	}
+	return query.Where(`posts.id IN (
+		SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE t.slug IN ?)`, slugs)
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// 3 requests refilled evenly over 30 seconds: one token every 10 seconds
+var testLimit = Limit{Requests: 3, Period: 30 * time.Second}
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestMemoryStoreTake(t *testing.T) {
+	store := NewMemoryStore()
+
+	steps := []struct {
+		name      string
+		after     time.Duration
+		allowed   bool
+		remaining int
+		// Values of the Retry-After and X-RateLimit-Reset headers
+		retryAfter string
+		reset      string
+	}{
+		{name: "first request", after: 0, allowed: true, remaining: 2, retryAfter: "0", reset: "10"},
+		{name: "second request", after: 0, allowed: true, remaining: 1, retryAfter: "0", reset: "20"},
+		{name: "last token", after: 0, allowed: true, remaining: 0, retryAfter: "0", reset: "30"},
+		{name: "empty bucket", after: 0, allowed: false, remaining: 0, retryAfter: "10", reset: "30"},
+		{name: "partly refilled", after: 4 * time.Second, allowed: false, remaining: 0, retryAfter: "6", reset: "26"},
+		{name: "one token refilled", after: 10 * time.Second, allowed: true, remaining: 0, retryAfter: "0", reset: "30"},
+		{name: "denied right after", after: 10 * time.Second, allowed: false, remaining: 0, retryAfter: "10", reset: "30"},
+		{name: "refilled up to the capacity", after: time.Hour, allowed: true, remaining: 2, retryAfter: "0", reset: "10"},
+	}
+
+	for _, step := range steps {
+		result, err := store.Take("client", testLimit, start.Add(step.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != step.allowed || result.Remaining != step.remaining {
+			t.Errorf("%s: expected allowed=%v remaining=%d, got allowed=%v remaining=%d",
+				step.name, step.allowed, step.remaining, result.Allowed, result.Remaining)
+		}
+		if got := ceilSeconds(result.RetryAfter); got != step.retryAfter {
+			t.Errorf("%s: expected Retry-After %s, got %s", step.name, step.retryAfter, got)
+		}
+		if got := ceilSeconds(result.ResetAfter); got != step.reset {
+			t.Errorf("%s: expected X-RateLimit-Reset %s, got %s", step.name, step.reset, got)
+		}
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < testLimit.Requests; i++ {
+		_, _ = store.Take("first", testLimit, start)
+	}
+
+	if result, _ := store.Take("first", testLimit, start); result.Allowed {
+		t.Error("expected the first client to be limited")
+	}
+	if result, _ := store.Take("second", testLimit, start); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("expected the second client to have its own bucket, got %+v", result)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	// The idle bucket is full again 10 seconds after its request, the busy one 30 seconds after its last
+	_, _ = store.Take("idle", testLimit, start)
+	for i := 0; i < testLimit.Requests; i++ {
+		_, _ = store.Take("busy", testLimit, start.Add(50*time.Second))
+	}
+	if _, ok := store.buckets["idle"]; !ok {
+		t.Fatal("expected no sweep before sweepInterval")
+	}
+
+	_, _ = store.Take("other", testLimit, start.Add(sweepInterval))
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("expected the full bucket to be swept")
+	}
+	if _, ok := store.buckets["busy"]; !ok {
+		t.Error("expected the bucket still refilling to be kept")
+	}
+
+	// A swept client starts again with a full bucket
+	if result, _ := store.Take("idle", testLimit, start.Add(sweepInterval)); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("expected a full bucket after the sweep, got %+v", result)
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-blog/services/config"
+	"go-blog/utils"
+	authUtils "go-blog/utils/auth"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Middleware limits the requests of each client of a route group with a token bucket.
+// Clients are identified by their user ID when authenticated, by their IP address otherwise,
+// so the middleware must run after the authentication middleware to limit per user.
+// Each scope has its own buckets, so the same client can be limited separately per route group.
+func Middleware(scope string, limit Limit) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !config.RateLimitEnabled {
+			ctx.Next()
+			return
+		}
+
+		result, err := CurrentStore().Take(clientKey(scope, ctx), limit, time.Now())
+		if err != nil {
+			// The API stays available when the store is down
+			log.Printf("Rate limit store error: %v", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("X-RateLimit-Reset", ceilSeconds(result.ResetAfter))
+
+		if !result.Allowed {
+			ctx.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, utils.NewErrorResponse("Too many requests, please try again later"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func clientKey(scope string, ctx *gin.Context) string {
+	if userModel, ok := authUtils.GetAuthenticatedUser(ctx); ok {
+		return fmt.Sprintf("%s:user:%d", scope, userModel.ID)
+	}
+	return fmt.Sprintf("%s:ip:%s", scope, ctx.ClientIP())
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
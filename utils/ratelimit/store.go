@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"go-blog/services/config"
+	"sync"
+	"time"
+)
+
+// Limit is the token bucket configuration: Requests tokens refilled evenly over Period
+type Limit = config.RateLimit
+
+// Result is the state of a bucket after taking a token from it
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long to wait for the next token when the request is not allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// Store keeps the token buckets. A store shared between instances (e.g. Redis) can be plugged in with SetStore.
+type Store interface {
+	// Take removes a token from the bucket of the key if there is one
+	Take(key string, limit Limit, now time.Time) (Result, error)
+}
+
+var (
+	storeMu      sync.RWMutex
+	currentStore Store = NewMemoryStore()
+)
+
+// CurrentStore returns the store used by the middlewares
+func CurrentStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return currentStore
+}
+
+// SetStore replaces the store used by the middlewares
+func SetStore(store Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	currentStore = store
+}
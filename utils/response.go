@@ -17,3 +17,19 @@ func NewErrorResponse(message string) ErrorResponse {
 		},
 	}
 }
+
+// ConflictResponse is returned when a resource changed since the version the client based its request on.
+// Current is the up-to-date representation of the resource.
+type ConflictResponse struct {
+	Errors  []map[string]string `json:"errors"`
+	Current interface{}         `json:"current"`
+}
+
+func NewConflictResponse(message string, current interface{}) ConflictResponse {
+	return ConflictResponse{
+		Errors: []map[string]string{
+			{"field": "version", "message": message},
+		},
+		Current: current,
+	}
+}
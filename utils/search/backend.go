@@ -0,0 +1,62 @@
+package search
+
+import (
+	postModel "go-blog/models/post"
+	"go-blog/models/user"
+	"time"
+)
+
+// PostQuery describes a post search: the text to match plus optional filters
+type PostQuery struct {
+	Text        string
+	CategoryIDs []uint
+	TagSlugs    []string
+	AllTags     bool
+	AuthorID    *uint
+	From        *time.Time
+	Before      *time.Time // exclusive end of the publication dates
+	Status      string
+	Viewer      *user.User
+	Limit       int
+	Offset      int
+}
+
+// CommentQuery describes a search over approved comments, on the posts the viewer may see
+type CommentQuery struct {
+	Text   string
+	PostID *uint
+	Viewer *user.User
+	Limit  int
+	Offset int
+}
+
+// PostHit is a post matching a search, with its relevance score
+type PostHit struct {
+	Post  postModel.Post
+	Score float64
+}
+
+// CommentHit is a comment matching a search, with its relevance score
+type CommentHit struct {
+	Comment postModel.Comment
+	Score   float64
+}
+
+// Backend runs searches over posts and comments. Results are sorted by relevance
+// and the total number of matches is returned alongside the requested page.
+type Backend interface {
+	SearchPosts(query PostQuery) ([]PostHit, int64, error)
+	SearchComments(query CommentQuery) ([]CommentHit, int64, error)
+}
+
+var currentBackend Backend = NewMySQLBackend()
+
+// CurrentBackend returns the backend used by the search endpoints
+func CurrentBackend() Backend {
+	return currentBackend
+}
+
+// SetBackend replaces the backend used by the search endpoints, e.g. with an in-memory index in tests
+func SetBackend(backend Backend) {
+	currentBackend = backend
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	highlightOpenTag  = "<mark>"
+	highlightCloseTag = "</mark>"
+	snippetContext    = 80
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	wordPattern       = regexp.MustCompile(`[\pL\pN]+`)
+)
+
+// Highlight returns an HTML-escaped snippet of the text around the first matching term,
+// with every matching term wrapped in <mark> tags. HTML tags in the text are stripped first.
+// An empty string is returned when none of the terms appear in the text.
+func Highlight(text string, terms []string) string {
+	plain := html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " "))
+	plain = strings.TrimSpace(whitespacePattern.ReplaceAllString(plain, " "))
+
+	matches := findTerms(plain, terms)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	start, end := snippetBounds(plain, matches[0][0], matches[0][1])
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString("…")
+	}
+	last := start
+	for _, match := range matches {
+		if match[0] < start || match[1] > end {
+			continue
+		}
+		builder.WriteString(html.EscapeString(plain[last:match[0]]))
+		builder.WriteString(highlightOpenTag)
+		builder.WriteString(html.EscapeString(plain[match[0]:match[1]]))
+		builder.WriteString(highlightCloseTag)
+		last = match[1]
+	}
+	builder.WriteString(html.EscapeString(plain[last:end]))
+	if end < len(plain) {
+		builder.WriteString("…")
+	}
+	return builder.String()
+}
+
+// findTerms returns the byte ranges of the words of the text matching one of the terms
+func findTerms(text string, terms []string) [][]int {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[strings.ToLower(term)] = true
+	}
+
+	var matches [][]int
+	for _, word := range wordPattern.FindAllStringIndex(text, -1) {
+		if wanted[strings.ToLower(text[word[0]:word[1]])] {
+			matches = append(matches, word)
+		}
+	}
+	return matches
+}
+
+// snippetBounds widens the match to about snippetContext bytes on each side, cutting on spaces
+func snippetBounds(text string, matchStart, matchEnd int) (int, int) {
+	start := matchStart - snippetContext
+	if start <= 0 {
+		start = 0
+	} else if i := strings.IndexByte(text[start:matchStart], ' '); i >= 0 {
+		start += i + 1
+	} else {
+		for !utf8.RuneStart(text[start]) {
+			start++
+		}
+	}
+
+	end := matchEnd + snippetContext
+	if end >= len(text) {
+		end = len(text)
+	} else if i := strings.LastIndexByte(text[matchEnd:end], ' '); i >= 0 {
+		end = matchEnd + i
+	} else {
+		for !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+	return start, end
+}
@@ -0,0 +1,207 @@
+package search
+
+import (
+	postModel "go-blog/models/post"
+	postUtil "go-blog/utils/post"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Field weights used to rank posts in the in-memory index
+const (
+	titleWeight   = 3
+	excerptWeight = 2
+	contentWeight = 1
+)
+
+// MemoryBackend is an in-process search index, mainly meant for tests and local development.
+// Posts and comments have to be indexed explicitly, comments being only found on indexed posts.
+type MemoryBackend struct {
+	mu       sync.RWMutex
+	posts    map[uint]postModel.Post
+	comments map[uint]postModel.Comment
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		posts:    make(map[uint]postModel.Post),
+		comments: make(map[uint]postModel.Comment),
+	}
+}
+
+// IndexPost adds or replaces a post in the index
+func (b *MemoryBackend) IndexPost(post postModel.Post) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.posts[post.ID] = post
+}
+
+// RemovePost removes a post from the index
+func (b *MemoryBackend) RemovePost(id uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.posts, id)
+}
+
+// IndexComment adds or replaces a comment in the index
+func (b *MemoryBackend) IndexComment(comment postModel.Comment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.comments[comment.ID] = comment
+}
+
+// RemoveComment removes a comment from the index
+func (b *MemoryBackend) RemoveComment(id uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.comments, id)
+}
+
+func (b *MemoryBackend) SearchPosts(query PostQuery) ([]PostHit, int64, error) {
+	terms := Terms(query.Text)
+
+	b.mu.RLock()
+	var hits []PostHit
+	for _, post := range b.posts {
+		if !postUtil.CanViewPost(query.Viewer, post) || !matchesPostFilters(post, query) {
+			continue
+		}
+		score := float64(titleWeight*countTerms(post.Title, terms) +
+			excerptWeight*countTerms(post.Excerpt, terms) +
+			contentWeight*countTerms(post.Content, terms))
+		if score > 0 {
+			hits = append(hits, PostHit{Post: post, Score: score})
+		}
+	}
+	b.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Post.ID < hits[j].Post.ID
+	})
+
+	total := int64(len(hits))
+	return paginate(hits, query.Offset, query.Limit), total, nil
+}
+
+func (b *MemoryBackend) SearchComments(query CommentQuery) ([]CommentHit, int64, error) {
+	terms := Terms(query.Text)
+
+	b.mu.RLock()
+	var hits []CommentHit
+	for _, comment := range b.comments {
+		if comment.Status != postModel.CommentStatusApproved {
+			continue
+		}
+		if query.PostID != nil && comment.PostID != *query.PostID {
+			continue
+		}
+		if post, ok := b.posts[comment.PostID]; !ok || !postUtil.CanViewPost(query.Viewer, post) {
+			continue
+		}
+		if score := float64(countTerms(comment.Content, terms)); score > 0 {
+			hits = append(hits, CommentHit{Comment: comment, Score: score})
+		}
+	}
+	b.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Comment.ID < hits[j].Comment.ID
+	})
+
+	total := int64(len(hits))
+	return paginate(hits, query.Offset, query.Limit), total, nil
+}
+
+func matchesPostFilters(post postModel.Post, query PostQuery) bool {
+	if query.Status != "" && post.Status != query.Status {
+		return false
+	}
+	if query.AuthorID != nil && (post.AuthorID == nil || *post.AuthorID != *query.AuthorID) {
+		return false
+	}
+
+	date := post.CreatedAt
+	if post.PublishedAt != nil {
+		date = *post.PublishedAt
+	}
+	if query.From != nil && date.Before(*query.From) {
+		return false
+	}
+	if query.Before != nil && !date.Before(*query.Before) {
+		return false
+	}
+
+	if !matchesTags(post, query.TagSlugs, query.AllTags) {
+		return false
+	}
+
+	if len(query.CategoryIDs) == 0 {
+		return true
+	}
+	for _, category := range post.Categories {
+		for _, id := range query.CategoryIDs {
+			if category.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesTags(post postModel.Post, slugs []string, matchAll bool) bool {
+	if len(slugs) == 0 {
+		return true
+	}
+	postTags := make(map[string]bool, len(post.Tags))
+	for _, tag := range post.Tags {
+		postTags[tag.Slug] = true
+	}
+	matched := 0
+	for _, slug := range slugs {
+		if postTags[slug] {
+			matched++
+		}
+	}
+	if matchAll {
+		return matched == len(slugs)
+	}
+	return matched > 0
+}
+
+// Terms splits a search text into lower-cased words
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func countTerms(text string, terms []string) int {
+	count := 0
+	for _, word := range Terms(text) {
+		for _, term := range terms {
+			if word == term {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := len(items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return items[offset:end]
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	postModel "go-blog/models/post"
+	"go-blog/models/user"
+	postUtil "go-blog/utils/post"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestBackend() *MemoryBackend {
+	authorID := uint(10)
+	backend := NewMemoryBackend()
+	backend.IndexPost(postModel.Post{ID: 1, Title: "Go generics", Content: "Type parameters in Go", Status: postModel.PostStatusPublished})
+	backend.IndexPost(postModel.Post{ID: 2, Title: "Channels", Content: "Go channels and go routines", Status: postModel.PostStatusPublished})
+	backend.IndexPost(postModel.Post{ID: 3, Title: "Go draft", Status: postModel.PostStatusDraft, AuthorID: &authorID})
+	backend.IndexComment(postModel.Comment{ID: 1, PostID: 1, Content: "Great go article", Status: postModel.CommentStatusApproved})
+	backend.IndexComment(postModel.Comment{ID: 2, PostID: 3, Content: "Go draft comment", Status: postModel.CommentStatusApproved})
+	backend.IndexComment(postModel.Comment{ID: 3, PostID: 1, Content: "Pending go comment", Status: postModel.CommentStatusPending})
+	return backend
+}
+
+func TestMemoryBackendSearchPosts(t *testing.T) {
+	backend := newTestBackend()
+
+	hits, total, err := backend.SearchPosts(PostQuery{Text: "go", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(hits) != 2 {
+		t.Fatalf("expected the 2 published posts, got %d hits out of %d", len(hits), total)
+	}
+	// The title weighs more than the content
+	if hits[0].Post.ID != 1 {
+		t.Errorf("expected post 1 to rank first, got post %d", hits[0].Post.ID)
+	}
+
+	author := &user.User{ID: 10, Role: string(user.RoleAuthor)}
+	hits, _, _ = backend.SearchPosts(PostQuery{Text: "go", Status: postModel.PostStatusDraft, Viewer: author, Limit: 10})
+	if len(hits) != 1 || hits[0].Post.ID != 3 {
+		t.Errorf("expected the author to find their draft only, got %v", hits)
+	}
+
+	hits, _, _ = backend.SearchPosts(PostQuery{Text: "go", Status: postModel.PostStatusDraft, Limit: 10})
+	if len(hits) != 0 {
+		t.Errorf("expected anonymous users not to find drafts, got %d hits", len(hits))
+	}
+}
+
+func TestMemoryBackendSearchComments(t *testing.T) {
+	backend := newTestBackend()
+
+	hits, total, err := backend.SearchComments(CommentQuery{Text: "go", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || hits[0].Comment.ID != 1 {
+		t.Fatalf("expected only the approved comment of the published post, got %d hits", total)
+	}
+
+	draftID := uint(3)
+	hits, _, _ = backend.SearchComments(CommentQuery{Text: "go", PostID: &draftID, Limit: 10})
+	if len(hits) != 0 {
+		t.Errorf("expected anonymous users not to find comments of drafts, got %d hits", len(hits))
+	}
+
+	admin := &user.User{ID: 1, Role: string(user.RoleAdmin)}
+	hits, _, _ = backend.SearchComments(CommentQuery{Text: "go", PostID: &draftID, Viewer: admin, Limit: 10})
+	if len(hits) != 1 || hits[0].Comment.ID != 2 {
+		t.Errorf("expected admins to find comments of drafts, got %v", hits)
+	}
+}
+
+func TestMemoryBackendSearchPostsUntilDate(t *testing.T) {
+	backend := NewMemoryBackend()
+	lastDay := time.Date(2024, 5, 31, 18, 30, 0, 0, time.Local)
+	nextDay := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	backend.IndexPost(postModel.Post{ID: 1, Title: "Go in May", Status: postModel.PostStatusPublished, PublishedAt: &lastDay})
+	backend.IndexPost(postModel.Post{ID: 2, Title: "Go in June", Status: postModel.PostStatusPublished, PublishedAt: &nextDay})
+
+	tests := []struct {
+		to   string
+		want []uint
+	}{
+		{to: "2024-05-31", want: []uint{1}},
+		{to: "2024-05-30", want: nil},
+		{to: "2024-06-01", want: []uint{1, 2}},
+		{to: lastDay.Format(time.RFC3339), want: nil},
+		{to: lastDay.Add(time.Second).Format(time.RFC3339), want: []uint{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.to, func(t *testing.T) {
+			before, err := postUtil.ParseDateEndParam(tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hits, _, err := backend.SearchPosts(PostQuery{Text: "go", Before: before, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []uint
+			for _, hit := range hits {
+				got = append(got, hit.Post.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected posts %v, got %v", tt.want, got)
+			}
+			for _, id := range tt.want {
+				if !slices.Contains(got, id) {
+					t.Errorf("expected post %d in %v", id, got)
+				}
+			}
+		})
+	}
+}
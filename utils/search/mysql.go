@@ -0,0 +1,134 @@
+package search
+
+import (
+	postModel "go-blog/models/post"
+	"go-blog/services/config"
+	postUtil "go-blog/utils/post"
+)
+
+const (
+	postMatchClause    = "MATCH(posts.title, posts.excerpt, posts.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	commentMatchClause = "MATCH(comments.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+)
+
+// MySQLBackend searches posts and comments using the MySQL FULLTEXT indexes
+type MySQLBackend struct{}
+
+func NewMySQLBackend() *MySQLBackend {
+	return &MySQLBackend{}
+}
+
+type scoredRow struct {
+	ID        uint
+	Relevance float64
+}
+
+func (b *MySQLBackend) SearchPosts(query PostQuery) ([]PostHit, int64, error) {
+	db := config.Db.Model(&postModel.Post{}).Where(postMatchClause, query.Text)
+	db = postUtil.ApplyPostVisibility(db, query.Viewer)
+	if query.Status != "" {
+		db = db.Where("posts.status = ?", query.Status)
+	}
+	if len(query.CategoryIDs) > 0 {
+		db = db.Where("posts.id IN (SELECT post_id FROM post_categories WHERE category_id IN ?)", query.CategoryIDs)
+	}
+	db = postUtil.ApplyTagFilter(db, query.TagSlugs, query.AllTags)
+	if query.AuthorID != nil {
+		db = db.Where("posts.author_id = ?", *query.AuthorID)
+	}
+	if query.From != nil {
+		db = db.Where("COALESCE(posts.published_at, posts.created_at) >= ?", *query.From)
+	}
+	if query.Before != nil {
+		db = db.Where("COALESCE(posts.published_at, posts.created_at) < ?", *query.Before)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []scoredRow
+	if err := db.
+		Select("posts.id AS id, "+postMatchClause+" AS relevance", query.Text).
+		Order("relevance DESC, posts.id ASC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(rows) == 0 {
+		return []PostHit{}, total, nil
+	}
+
+	var posts []postModel.Post
+	if err := config.Db.Preload("Categories").Preload("Tags").Preload("Author").Preload("CoverImage.Variants").Where("id IN ?", rowIDs(rows)).Find(&posts).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]postModel.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	hits := make([]PostHit, 0, len(rows))
+	for _, row := range rows {
+		if post, ok := byID[row.ID]; ok {
+			hits = append(hits, PostHit{Post: post, Score: row.Relevance})
+		}
+	}
+	return hits, total, nil
+}
+
+func (b *MySQLBackend) SearchComments(query CommentQuery) ([]CommentHit, int64, error) {
+	db := config.Db.Model(&postModel.Comment{}).
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where(commentMatchClause, query.Text).
+		Where("comments.status = ?", postModel.CommentStatusApproved)
+	db = postUtil.ApplyPostVisibility(db, query.Viewer)
+	if query.PostID != nil {
+		db = db.Where("comments.post_id = ?", *query.PostID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []scoredRow
+	if err := db.
+		Select("comments.id AS id, "+commentMatchClause+" AS relevance", query.Text).
+		Order("relevance DESC, comments.id ASC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(rows) == 0 {
+		return []CommentHit{}, total, nil
+	}
+
+	var comments []postModel.Comment
+	if err := config.Db.Preload("User").Where("id IN ?", rowIDs(rows)).Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]postModel.Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+
+	hits := make([]CommentHit, 0, len(rows))
+	for _, row := range rows {
+		if comment, ok := byID[row.ID]; ok {
+			hits = append(hits, CommentHit{Comment: comment, Score: row.Relevance})
+		}
+	}
+	return hits, total, nil
+}
+
+func rowIDs(rows []scoredRow) []uint {
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids
+}
@@ -0,0 +1,18 @@
+package user
+
+import (
+	"go-blog/models/user"
+	"strings"
+)
+
+var roleRanks = map[user.Role]int{
+	user.RoleReader:      1,
+	user.RoleContributor: 2,
+	user.RoleAuthor:      3,
+	user.RoleAdmin:       4,
+}
+
+// IsDemotion reports whether going from the old role to the new one removes privileges
+func IsDemotion(oldRole, newRole string) bool {
+	return roleRanks[user.Role(strings.ToUpper(newRole))] < roleRanks[user.Role(strings.ToUpper(oldRole))]
+}